@@ -4,12 +4,14 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"kenyan-food-delivery/internal/auth"
 	"kenyan-food-delivery/internal/config"
 	"kenyan-food-delivery/internal/database"
 	"kenyan-food-delivery/internal/handlers"
 	"kenyan-food-delivery/internal/middleware"
+	"kenyan-food-delivery/internal/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -23,6 +25,9 @@ func main() {
 
 	// Load configuration
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
 
 	// Initialize JWT auth
 	auth.Initialize(cfg.JWTSecret)
@@ -45,6 +50,13 @@ func main() {
 
 	router := gin.Default()
 
+	// Rate limits and lockouts are keyed by client IP, so forwarding headers are only
+	// believed from the configured load balancers or platform
+	router.TrustedPlatform = cfg.TrustedPlatform
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES: ", err)
+	}
+
 	// Add middleware
 	router.Use(middleware.CORS())
 	router.Use(middleware.Logger())
@@ -53,8 +65,33 @@ func main() {
 	// Initialize handlers
 	h := handlers.New(db, cfg)
 
+	// Reject access tokens whose session has been revoked
+	auth.SetSessionValidator(h.Services().Session.ValidateSession)
+
+	// Resolve route permissions from the roles stored in the database
+	middleware.SetAuthorizer(h.Services().RBAC)
+	middleware.SetAPIKeyAuthenticator(h.Services().APIKey)
+	if err := h.Services().RBAC.PromoteSuperAdmins(cfg.SuperAdminEmails); err != nil {
+		log.Printf("Failed to assign super admin roles: %v", err)
+	}
+
+	// Delete used and expired email tokens and old notifications in the background
+	go h.Services().Token.RunCleanup(time.Hour)
+	go h.Services().Notification.RunCleanup(24 * time.Hour)
+
+	// Send queued emails and text messages, retrying failures
+	go h.Services().Outbox.RunWorker(15 * time.Second)
+	go h.Services().Outbox.RunCleanup(24 * time.Hour)
+
+	// Deliver webhooks to restaurant partners, retrying failures
+	go h.Services().Webhook.RunWorker(15 * time.Second)
+	go h.Services().Webhook.RunCleanup(24 * time.Hour)
+
+	// Reject orders restaurants did not accept in time and queue their refunds
+	go h.Services().Order.RunAcceptWindow(30 * time.Second)
+
 	// Setup routes
-	setupRoutes(router, h)
+	setupRoutes(router, h, cfg)
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -66,7 +103,12 @@ func main() {
 	log.Fatal(http.ListenAndServe("0.0.0.0:"+port, router))
 }
 
-func setupRoutes(router *gin.Engine, h *handlers.Handler) {
+func setupRoutes(router *gin.Engine, h *handlers.Handler, cfg *config.Config) {
+	// Rate limiting, keyed per client with a separate budget per route group
+	limitStore := middleware.NewMemoryRateLimitStore(50000)
+	limits := middleware.NewRateLimitPolicies(cfg.RateLimitRequests, time.Duration(cfg.RateLimitWindow)*time.Minute)
+	defaultLimit := middleware.RateLimit(limitStore, limits.Default)
+
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -79,73 +121,187 @@ func setupRoutes(router *gin.Engine, h *handlers.Handler) {
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
-		// Authentication routes
+		// Authentication routes that check passwords or codes share the strict per-IP budget
 		auth := v1.Group("/auth")
+		auth.Use(middleware.RateLimit(limitStore, limits.Auth))
 		{
 			auth.POST("/register", h.Register)
 			auth.POST("/login", h.Login)
-			auth.POST("/refresh", h.RefreshToken)
-			auth.POST("/logout", middleware.AuthRequired(), h.Logout)
 			auth.POST("/verify-email", h.VerifyEmail)
 			auth.GET("/verify-email", h.VerifyEmailLink) // For email links
 			auth.POST("/forgot-password", h.ForgotPassword)
 			auth.POST("/reset-password", h.ResetPassword)
 			auth.GET("/reset-password", h.ResetPasswordLink) // For password reset links
 			auth.POST("/resend-verification", h.ResendVerificationEmail)
+			auth.POST("/phone/request-code", h.RequestPhoneLoginCode)
+			auth.POST("/phone/login", h.PhoneLogin)
+			auth.POST("/mfa/verify", h.VerifyMFA)
+			auth.POST("/mfa/enroll", h.StartMFAEnrollment)
+			auth.POST("/mfa/enroll/confirm", h.ConfirmMFAEnrollment)
+		}
+
+		// Session management and policy reads, which many users behind one NAT call routinely
+		authSessions := v1.Group("/auth")
+		{
+			authSessions.GET("/password-policy", defaultLimit, h.GetPasswordPolicy)
+			authSessions.POST("/refresh", defaultLimit, h.RefreshToken)
+			authSessions.POST("/logout", middleware.AuthRequired(), defaultLimit, h.Logout)
+			authSessions.POST("/logout-all", middleware.AuthRequired(), defaultLimit, h.LogoutAllDevices)
+			authSessions.GET("/sessions", middleware.AuthRequired(), defaultLimit, h.GetSessions)
+			authSessions.DELETE("/sessions/:id", middleware.AuthRequired(), defaultLimit, h.RevokeSession)
 		}
 
 		// User routes
 		users := v1.Group("/users")
-		users.Use(middleware.AuthRequired())
+		users.Use(middleware.AuthRequired(), defaultLimit)
 		{
 			users.GET("/profile", h.GetProfile)
 			users.PUT("/profile", h.UpdateProfile)
+			users.GET("/permissions", h.GetMyPermissions)
+			users.POST("/phone/send-code", h.SendPhoneVerificationCode)
+			users.POST("/phone/verify", h.VerifyPhone)
+			users.GET("/mfa", h.GetMFAStatus)
+			users.POST("/mfa/setup", h.SetupMFA)
+			users.POST("/mfa/enable", h.EnableMFA)
+			users.POST("/mfa/disable", h.DisableMFA)
+			users.POST("/mfa/recovery-codes", h.RegenerateRecoveryCodes)
+			users.GET("/notification-preferences", h.GetNotificationPreferences)
+			users.PUT("/notification-preferences", h.UpdateNotificationPreferences)
+			users.GET("/devices", h.GetDevices)
+			users.POST("/devices", h.RegisterDevice)
+			users.DELETE("/devices", h.UnregisterDevice)
 			users.POST("/address", h.AddAddress)
 			users.GET("/addresses", h.GetAddresses)
 			users.PUT("/addresses/:id", h.UpdateAddress)
 			users.DELETE("/addresses/:id", h.DeleteAddress)
 		}
 
+		// Email unsubscribe links work without signing in
+		v1.GET("/notifications/unsubscribe", defaultLimit, h.UnsubscribeLink)
+		v1.POST("/notifications/unsubscribe", defaultLimit, h.Unsubscribe)
+
+		// Notification inbox routes
+		notifications := v1.Group("/notifications")
+		notifications.Use(middleware.AuthRequired(), defaultLimit)
+		{
+			notifications.GET("", h.GetNotifications)
+			notifications.GET("/unread-count", h.GetUnreadNotificationCount)
+			notifications.PUT("/read-all", h.MarkAllNotificationsRead)
+			notifications.PUT("/:id/read", h.MarkNotificationRead)
+			notifications.DELETE("/:id", h.DeleteNotification)
+		}
+
 		// Restaurant routes
 		restaurants := v1.Group("/restaurants")
+		restaurants.Use(middleware.RateLimit(limitStore, limits.Browse))
 		{
 			restaurants.GET("", h.GetRestaurants)
 			restaurants.GET("/:id", h.GetRestaurant)
 			restaurants.GET("/:id/menu", h.GetRestaurantMenu)
+			restaurants.GET("/:id/reviews", h.GetRestaurantReviews)
 			restaurants.GET("/search", h.SearchRestaurants)
+			restaurants.GET("/nearby", middleware.OptionalAuth(), h.GetNearbyRestaurants)
 			restaurants.GET("/cuisine/:cuisine", h.GetRestaurantsByCuisine)
 			restaurants.GET("/location/:county", h.GetRestaurantsByLocation)
 		}
 
 		// Restaurant owner routes
 		restaurantOwner := v1.Group("/restaurant-owner")
-		restaurantOwner.Use(middleware.AuthRequired(), middleware.RestaurantOwnerRequired())
+		restaurantOwner.Use(middleware.AuthOrAPIKey(), defaultLimit)
 		{
-			restaurantOwner.POST("/restaurant", h.CreateRestaurant)
-			restaurantOwner.PUT("/restaurant/:id", h.UpdateRestaurant)
-			restaurantOwner.GET("/restaurant/:id/orders", h.GetRestaurantOrders)
-			restaurantOwner.PUT("/orders/:id/status", h.UpdateOrderStatus)
+			restaurantOwner.POST("/restaurant", middleware.RequirePermission(models.PermissionRestaurantsCreate), h.CreateRestaurant)
+			restaurantOwner.PUT("/restaurant/:id", middleware.RequireResourcePermission(models.PermissionRestaurantsUpdate, models.ResourceRestaurant, "id"), h.UpdateRestaurant)
+			restaurantOwner.GET("/restaurant/:id/orders", middleware.RequireResourcePermission(models.PermissionOrdersRead, models.ResourceRestaurant, "id"), h.GetRestaurantOrders)
+			restaurantOwner.GET("/restaurant/:id/orders/stream", middleware.RequireResourcePermission(models.PermissionOrdersRead, models.ResourceRestaurant, "id"), h.StreamRestaurantOrders)
+			restaurantOwner.POST("/orders/:id/accept", middleware.RequireResourcePermission(models.PermissionOrdersUpdateStatus, models.ResourceOrder, "id"), h.AcceptOrder)
+			restaurantOwner.POST("/orders/:id/reject", middleware.RequireResourcePermission(models.PermissionOrdersUpdateStatus, models.ResourceOrder, "id"), h.RejectOrder)
+			restaurantOwner.POST("/orders/:id/ready", middleware.RequireResourcePermission(models.PermissionOrdersUpdateStatus, models.ResourceOrder, "id"), h.MarkOrderReady)
+
+			// Busy mode and order throttling
+			restaurantOwner.GET("/restaurant/:id/busy-mode", middleware.RequireResourcePermission(models.PermissionOrdersThrottle, models.ResourceRestaurant, "id"), h.GetBusyMode)
+			restaurantOwner.PUT("/restaurant/:id/busy-mode", middleware.RequireResourcePermission(models.PermissionOrdersThrottle, models.ResourceRestaurant, "id"), h.SetBusyMode)
+			restaurantOwner.PUT("/restaurant/:id/order-capacity", middleware.RequireResourcePermission(models.PermissionRestaurantsUpdate, models.ResourceRestaurant, "id"), h.SetOrderCapacity)
+			restaurantOwner.PUT("/orders/:id/status", middleware.RequireResourcePermission(models.PermissionOrdersUpdateStatus, models.ResourceOrder, "id"), h.UpdateOrderStatus)
+			restaurantOwner.GET("/orders/:id/history", middleware.RequireResourcePermission(models.PermissionOrdersRead, models.ResourceOrder, "id"), h.GetOrderHistory)
 			
 			// Menu management
-			restaurantOwner.POST("/restaurant/:id/menu", h.AddMenuItem)
-			restaurantOwner.PUT("/menu/:id", h.UpdateMenuItem)
-			restaurantOwner.DELETE("/menu/:id", h.DeleteMenuItem)
+			restaurantOwner.POST("/restaurant/:id/menu", middleware.RequireResourcePermission(models.PermissionMenuWrite, models.ResourceRestaurant, "id"), h.AddMenuItem)
+			restaurantOwner.PUT("/menu/:id", middleware.RequireResourcePermission(models.PermissionMenuWrite, models.ResourceMenuItem, "id"), h.UpdateMenuItem)
+			restaurantOwner.DELETE("/menu/:id", middleware.RequireResourcePermission(models.PermissionMenuWrite, models.ResourceMenuItem, "id"), h.DeleteMenuItem)
+			restaurantOwner.PUT("/menu/:id/availability", middleware.RequireResourcePermission(models.PermissionMenuToggle, models.ResourceMenuItem, "id"), h.SetMenuItemStatus)
+
+			// Staff management
+			manageStaff := middleware.RequireResourcePermission(models.PermissionStaffManage, models.ResourceRestaurant, "id")
+			restaurantOwner.GET("/restaurant/:id/staff", manageStaff, h.GetRestaurantStaff)
+			restaurantOwner.POST("/restaurant/:id/staff", manageStaff, h.InviteStaff)
+			restaurantOwner.PUT("/restaurant/:id/staff/:staffId", manageStaff, h.UpdateStaffRole)
+			restaurantOwner.DELETE("/restaurant/:id/staff/:staffId", manageStaff, h.RevokeStaff)
+
+			// Webhooks for partner systems such as a POS
+			manageWebhooks := middleware.RequireResourcePermission(models.PermissionWebhooksManage, models.ResourceRestaurant, "id")
+			restaurantOwner.GET("/restaurant/:id/webhooks", manageWebhooks, h.GetWebhooks)
+			restaurantOwner.POST("/restaurant/:id/webhooks", manageWebhooks, h.CreateWebhook)
+			restaurantOwner.PUT("/restaurant/:id/webhooks/:webhookId", manageWebhooks, h.UpdateWebhook)
+			restaurantOwner.DELETE("/restaurant/:id/webhooks/:webhookId", manageWebhooks, h.DeleteWebhook)
+			restaurantOwner.GET("/restaurant/:id/webhook-deliveries", manageWebhooks, h.GetWebhookDeliveries)
+			restaurantOwner.POST("/restaurant/:id/webhook-deliveries/:deliveryId/redeliver", manageWebhooks, h.RedeliverWebhook)
+
+			// API keys for partner systems, sent in the X-API-Key header
+			manageAPIKeys := middleware.RequireResourcePermission(models.PermissionAPIKeysManage, models.ResourceRestaurant, "id")
+			restaurantOwner.GET("/restaurant/:id/api-keys", manageAPIKeys, h.GetAPIKeys)
+			restaurantOwner.POST("/restaurant/:id/api-keys", manageAPIKeys, h.CreateAPIKey)
+			restaurantOwner.DELETE("/restaurant/:id/api-keys/:keyId", manageAPIKeys, h.RevokeAPIKey)
+
+			// Review replies
+			restaurantOwner.POST("/reviews/:id/reply", middleware.RequireResourcePermission(models.PermissionReviewsReply, models.ResourceReview, "id"), h.ReplyToReview)
+		}
+
+		// Staff membership routes for invited users
+		staff := v1.Group("/staff")
+		staff.Use(middleware.AuthRequired(), defaultLimit)
+		{
+			staff.GET("/invitations", h.GetMyStaffInvitations)
+			staff.POST("/invitations/accept", h.AcceptStaffInvitation)
+			staff.GET("/restaurants", h.GetMyStaffRestaurants)
 		}
 
 		// Order routes
 		orders := v1.Group("/orders")
-		orders.Use(middleware.AuthRequired())
+		orders.Use(middleware.AuthRequired(), defaultLimit)
 		{
 			orders.POST("", h.CreateOrder)
 			orders.GET("", h.GetUserOrders)
-			orders.GET("/:id", h.GetOrder)
-			orders.PUT("/:id/cancel", h.CancelOrder)
-			orders.GET("/:id/track", h.TrackOrder)
+			orders.GET("/:id", middleware.RequireResourcePermission(models.PermissionOrdersRead, models.ResourceOrder, "id"), h.GetOrder)
+			orders.PUT("/:id/cancel", middleware.RequireResourcePermission(models.PermissionOrdersCancel, models.ResourceOrder, "id"), h.CancelOrder)
+			orders.GET("/:id/track", middleware.RequireResourcePermission(models.PermissionOrdersRead, models.ResourceOrder, "id"), h.TrackOrder)
+			orders.POST("/:id/review", h.ReviewOrder)
+		}
+
+		// Review routes
+		reviews := v1.Group("/reviews")
+		reviews.Use(middleware.AuthRequired(), defaultLimit)
+		{
+			reviews.POST("/:id/helpful", h.MarkReviewHelpful)
+			reviews.DELETE("/:id/helpful", h.UnmarkReviewHelpful)
+			reviews.POST("/:id/photos", h.UploadReviewPhoto)
+		}
+
+		// Promo routes
+		promos := v1.Group("/promos")
+		promos.Use(middleware.AuthRequired(), defaultLimit)
+		{
+			promos.POST("/validate", h.ValidatePromoCode)
+		}
+
+		// SMS provider callbacks
+		sms := v1.Group("/sms")
+		{
+			sms.POST("/delivery-report", h.SMSDeliveryReport)
 		}
 
 		// Payment routes
 		payments := v1.Group("/payments")
-		payments.Use(middleware.AuthRequired())
+		payments.Use(middleware.AuthRequired(), middleware.RateLimit(limitStore, limits.Payments))
 		{
 			payments.POST("/mpesa/stk-push", h.InitiateMpesaPayment)
 			payments.POST("/mpesa/callback", h.MpesaCallback)
@@ -154,7 +310,7 @@ func setupRoutes(router *gin.Engine, h *handlers.Handler) {
 
 		// Delivery routes
 		delivery := v1.Group("/delivery")
-		delivery.Use(middleware.AuthRequired())
+		delivery.Use(middleware.AuthRequired(), defaultLimit)
 		{
 			delivery.GET("/zones", h.GetDeliveryZones)
 			delivery.GET("/fee", h.CalculateDeliveryFee)
@@ -162,7 +318,7 @@ func setupRoutes(router *gin.Engine, h *handlers.Handler) {
 
 		// Driver routes
 		driver := v1.Group("/driver")
-		driver.Use(middleware.AuthRequired(), middleware.DriverRequired())
+		driver.Use(middleware.AuthRequired(), middleware.RequirePermission(models.PermissionDeliveriesFulfil), middleware.RateLimit(limitStore, limits.Drivers))
 		{
 			driver.GET("/orders/available", h.GetAvailableDeliveries)
 			driver.POST("/orders/:id/accept", h.AcceptDelivery)
@@ -172,14 +328,50 @@ func setupRoutes(router *gin.Engine, h *handlers.Handler) {
 
 		// Admin routes
 		admin := v1.Group("/admin")
-		admin.Use(middleware.AuthRequired(), middleware.AdminRequired())
+		admin.Use(middleware.AuthRequired())
 		{
-			admin.GET("/stats", h.GetAdminStats)
-			admin.GET("/users", h.GetAllUsers)
-			admin.GET("/restaurants", h.GetAllRestaurants)
-			admin.GET("/orders", h.GetAllOrders)
-			admin.PUT("/restaurants/:id/approve", h.ApproveRestaurant)
-			admin.PUT("/users/:id/status", h.UpdateUserStatus)
+			admin.GET("/stats", middleware.RequireGlobalPermission(models.PermissionStatsRead), h.GetAdminStats)
+			admin.GET("/users", middleware.RequireGlobalPermission(models.PermissionUsersRead), h.GetAllUsers)
+			admin.GET("/restaurants", middleware.RequireGlobalPermission(models.PermissionRestaurantsRead), h.GetAllRestaurants)
+			admin.GET("/orders", middleware.RequireGlobalPermission(models.PermissionOrdersRead), h.GetAllOrders)
+			admin.PUT("/restaurants/:id/approve", middleware.RequireGlobalPermission(models.PermissionRestaurantsApprove), h.ApproveRestaurant)
+			admin.PUT("/users/:id/status", middleware.RequireGlobalPermission(models.PermissionUsersManage), h.UpdateUserStatus)
+			admin.POST("/users/:id/logout", middleware.RequireGlobalPermission(models.PermissionUsersManage), h.ForceLogoutUser)
+			admin.POST("/users/:id/unlock", middleware.RequireGlobalPermission(models.PermissionUsersUnlock), h.UnlockUser)
+			admin.POST("/payments/:id/refund", middleware.RequireGlobalPermission(models.PermissionOrdersRefund), h.CompleteRefund)
+
+			// Promo code management
+			promoAdmin := admin.Group("/promos", middleware.RequireGlobalPermission(models.PermissionPromosManage))
+			promoAdmin.GET("", h.GetPromoCodes)
+			promoAdmin.POST("", h.CreatePromoCode)
+			promoAdmin.PUT("/:id", h.UpdatePromoCode)
+			promoAdmin.DELETE("/:id", h.DeactivatePromoCode)
+
+			// Role management
+			manageRoles := middleware.RequireGlobalPermission(models.PermissionRolesManage)
+			admin.GET("/permissions", manageRoles, h.GetPermissions)
+			admin.GET("/roles", manageRoles, h.GetRoles)
+			admin.POST("/roles", manageRoles, h.CreateRole)
+			admin.PUT("/roles/:id", manageRoles, h.UpdateRole)
+			admin.DELETE("/roles/:id", manageRoles, h.DeleteRole)
+			admin.GET("/users/:id/roles", manageRoles, h.GetUserRoles)
+			admin.POST("/users/:id/roles", manageRoles, h.AssignUserRole)
+			admin.DELETE("/users/:id/roles/:roleId", manageRoles, h.RemoveUserRole)
+
+			// Email and SMS outbox
+			manageMessages := middleware.RequireGlobalPermission(models.PermissionMessagesManage)
+			admin.GET("/outbox", manageMessages, h.GetOutboxMessages)
+			admin.POST("/outbox/:id/resend", manageMessages, h.ResendOutboxMessage)
+		}
+
+		// Development tools, never registered in production
+		if cfg.Environment != "production" {
+			dev := v1.Group("/dev")
+			{
+				dev.GET("/emails", h.GetCapturedEmails)
+				dev.GET("/emails/:id", h.GetCapturedEmail)
+				dev.DELETE("/emails", h.ClearCapturedEmails)
+			}
 		}
 	}
 }
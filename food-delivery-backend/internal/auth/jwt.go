@@ -13,27 +13,49 @@ import (
 
 // Claims represents JWT claims
 type Claims struct {
-	UserID uint             `json:"user_id"`
-	Email  string           `json:"email"`
-	Role   models.UserRole  `json:"role"`
+	UserID    uint             `json:"user_id"`
+	Email     string           `json:"email"`
+	Role      models.UserRole  `json:"role"`
+	SessionID string           `json:"sid,omitempty"` // Session family the token belongs to
 	jwt.RegisteredClaims
 }
 
+// SessionValidator reports whether a session is still active
+type SessionValidator func(sessionID string) error
+
 var jwtSecret []byte
+var sessionValidator SessionValidator
 
 // Initialize sets the JWT secret
 func Initialize(secret string) {
 	jwtSecret = []byte(secret)
 }
 
-// GenerateToken generates a JWT token for a user
-func GenerateToken(user *models.User) (string, error) {
+// SetSessionValidator registers the check used to reject tokens from revoked sessions
+func SetSessionValidator(validator SessionValidator) {
+	sessionValidator = validator
+}
+
+// ValidateSession checks that the session a token was issued for has not been revoked
+func ValidateSession(claims *Claims) error {
+	if claims.SessionID == "" {
+		return errors.New("token is not bound to a session")
+	}
+	if sessionValidator == nil {
+		return nil
+	}
+	return sessionValidator(claims.SessionID)
+}
+
+// GenerateToken generates a JWT token for a user session
+func GenerateToken(user *models.User, sessionID string) (string, error) {
 	expirationTime := time.Now().Add(24 * time.Hour)
 	
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -50,15 +72,15 @@ func GenerateToken(user *models.User) (string, error) {
 	return tokenString, nil
 }
 
-// GenerateRefreshToken generates a refresh token
-func GenerateRefreshToken(user *models.User) (string, error) {
-	expirationTime := time.Now().Add(7 * 24 * time.Hour) // 7 days
-	
+// GenerateRefreshToken generates a refresh token identified by tokenID (the jti)
+func GenerateRefreshToken(user *models.User, sessionID, tokenID string, expirationTime time.Time) (string, error) {
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "kenyan-food-delivery-refresh",
@@ -90,6 +112,11 @@ func ValidateToken(tokenString string) (*Claims, error) {
 		return nil, errors.New("invalid token")
 	}
 
+	// Refresh tokens share the signing key, so they must not be accepted as access tokens
+	if claims.Issuer != "kenyan-food-delivery" {
+		return nil, errors.New("invalid token issuer")
+	}
+
 	return claims, nil
 }
 
@@ -113,10 +140,64 @@ func ValidateRefreshToken(tokenString string) (*Claims, error) {
 		return nil, errors.New("invalid refresh token issuer")
 	}
 
+	if claims.ID == "" || claims.SessionID == "" {
+		return nil, errors.New("refresh token is not bound to a session")
+	}
+
 	return claims, nil
 }
 
-// GenerateRandomToken generates a random token for email verification or password reset
+// MFA challenge purposes, carried in the token subject
+const (
+	MFAChallengeVerify = "verify" // Enter a code from an enrolled authenticator
+	MFAChallengeEnroll = "enroll" // Enrol an authenticator before the first sign-in
+)
+
+// MFAChallengeLifetime is how long a user has to complete the second login step
+const MFAChallengeLifetime = 5 * time.Minute
+
+// GenerateMFAChallengeToken generates a short-lived token proving the password step of a login passed
+func GenerateMFAChallengeToken(user *models.User, purpose string) (string, error) {
+	claims := &Claims{
+		UserID: user.ID,
+		Email:  user.Email,
+		Role:   user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   purpose,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(MFAChallengeLifetime)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "kenyan-food-delivery-mfa",
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// ValidateMFAChallengeToken validates an MFA challenge token issued for the given purpose
+func ValidateMFAChallengeToken(tokenString, purpose string) (*Claims, error) {
+	claims := &Claims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, errors.New("invalid challenge token")
+	}
+
+	if claims.Issuer != "kenyan-food-delivery-mfa" || claims.Subject != purpose {
+		return nil, errors.New("invalid challenge token")
+	}
+
+	return claims, nil
+}
+
+// GenerateRandomToken generates a random hex token of length bytes
 func GenerateRandomToken(length int) (string, error) {
 	bytes := make([]byte, length)
 	if _, err := rand.Read(bytes); err != nil {
@@ -125,13 +206,3 @@ func GenerateRandomToken(length int) (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
-// GenerateEmailVerificationToken generates a token for email verification
-func GenerateEmailVerificationToken() (string, error) {
-	return GenerateRandomToken(32) // 64 character hex string
-}
-
-// GeneratePasswordResetToken generates a token for password reset
-func GeneratePasswordResetToken() (string, error) {
-	return GenerateRandomToken(32) // 64 character hex string
-}
-
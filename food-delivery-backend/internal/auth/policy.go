@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// MaxPasswordLength is the longest password bcrypt can hash without silently truncating it
+const MaxPasswordLength = 72
+
+// Password rules reported in policy violations
+const (
+	RuleMinLength    = "min_length"
+	RuleMaxLength    = "max_length"
+	RuleUppercase    = "uppercase"
+	RuleLowercase    = "lowercase"
+	RuleDigit        = "digit"
+	RuleSymbol       = "symbol"
+	RulePersonalInfo = "personal_info"
+	RuleCommon       = "common"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var (
+	commonPasswords     map[string]bool
+	commonPasswordsOnce sync.Once
+)
+
+// PasswordPolicy describes what a new password must look like
+type PasswordPolicy struct {
+	MinLength     int  `json:"min_length"`
+	MaxLength     int  `json:"max_length"`
+	RequireUpper  bool `json:"require_uppercase"`
+	RequireLower  bool `json:"require_lowercase"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+	RejectCommon  bool `json:"reject_common"`
+}
+
+// PasswordViolation is one rule a password broke
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a password broke so they can all be shown at once
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Requirements describes the policy in sentences, for showing next to a password field
+func (p PasswordPolicy) Requirements() []string {
+	requirements := []string{fmt.Sprintf("At least %d characters long", p.MinLength)}
+	if p.RequireUpper {
+		requirements = append(requirements, "Contains at least one uppercase letter")
+	}
+	if p.RequireLower {
+		requirements = append(requirements, "Contains at least one lowercase letter")
+	}
+	if p.RequireDigit {
+		requirements = append(requirements, "Contains at least one number")
+	}
+	if p.RequireSymbol {
+		requirements = append(requirements, "Contains at least one special character")
+	}
+	requirements = append(requirements, "Does not contain your name, email or phone number")
+	if p.RejectCommon {
+		requirements = append(requirements, "Is not a commonly used password")
+	}
+	return requirements
+}
+
+// Validate checks a password against the policy. personal holds details about the user,
+// such as their name, email or phone number, that must not appear in the password.
+// It returns a *PasswordPolicyError listing every broken rule.
+func (p PasswordPolicy) Validate(password string, personal ...string) error {
+	var violations []PasswordViolation
+	add := func(rule, message string) {
+		violations = append(violations, PasswordViolation{Rule: rule, Message: message})
+	}
+
+	length := len([]rune(password))
+	if length < p.MinLength {
+		add(RuleMinLength, fmt.Sprintf("Password must be at least %d characters long", p.MinLength))
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		add(RuleMaxLength, fmt.Sprintf("Password must be at most %d characters long", p.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		add(RuleUppercase, "Password must contain an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		add(RuleLowercase, "Password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		add(RuleDigit, "Password must contain a number")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		add(RuleSymbol, "Password must contain a special character")
+	}
+
+	lowered := strings.ToLower(password)
+	for _, value := range personal {
+		// Very short values such as initials would reject too many good passwords
+		value = strings.ToLower(strings.TrimSpace(value))
+		if len(value) >= 3 && strings.Contains(lowered, value) {
+			add(RulePersonalInfo, "Password must not contain your name, email or phone number")
+			break
+		}
+	}
+
+	if p.RejectCommon && isCommonPassword(lowered) {
+		add(RuleCommon, "Password is too common, please choose another")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// isCommonPassword reports whether a lowercased password, or the word left after removing
+// digits and symbols from its ends (as in "Sunshine2024!"), is on the bundled list
+func isCommonPassword(password string) bool {
+	commonPasswordsOnce.Do(loadCommonPasswords)
+
+	if commonPasswords[password] {
+		return true
+	}
+	base := strings.TrimFunc(password, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	return len(base) >= 4 && commonPasswords[base]
+}
+
+// loadCommonPasswords parses the embedded list, skipping blank lines and comments
+func loadCommonPasswords() {
+	commonPasswords = make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(commonPasswordList))
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line != "" && !strings.HasPrefix(line, "#") {
+			commonPasswords[line] = true
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config holds all configuration for the application
@@ -11,6 +13,17 @@ type Config struct {
 	DatabaseURL   string
 	JWTSecret     string
 	Port          string
+
+	// Access Control
+	SuperAdminEmails []string // Users granted the super admin role at startup
+
+	// Password Policy
+	PasswordMinLength     int
+	PasswordRequireUpper  bool
+	PasswordRequireLower  bool
+	PasswordRequireDigit  bool
+	PasswordRequireSymbol bool
+	PasswordRejectCommon  bool // Check against the bundled list of common and breached passwords
 	
 	// M-Pesa Configuration
 	MpesaConsumerKey    string
@@ -20,12 +33,28 @@ type Config struct {
 	MpesaEnvironment    string // sandbox or production
 	
 	// Email Configuration
-	SMTPHost     string
-	SMTPPort     int
-	SMTPUsername string
-	SMTPPassword string
-	EmailFrom    string
-	BackendURL   string
+	EmailTransport   string // smtp or capture
+	SMTPHost         string
+	SMTPPort         int
+	SMTPUsername     string
+	SMTPPassword     string
+	SMTPSecurity     string // starttls, tls (implicit, usually port 465) or none
+	EmailFrom        string
+	EmailCaptureDir  string // Where the capture transport writes .eml files; empty keeps them in memory only
+	BackendURL       string
+
+	// SMS Configuration
+	SMSProvider               string // africastalking or fake
+	SMSSenderID               string // Registered sender ID or short code, optional
+	SMSCallbackToken          string // Shared secret expected on delivery report callbacks
+	AfricasTalkingUsername    string
+	AfricasTalkingAPIKey      string
+	AfricasTalkingEnvironment string // sandbox or production
+
+	// Push Notification Configuration
+	PushProvider       string // fcm or fake
+	FCMCredentialsFile string // Service account key file
+	FCMProjectID       string // Overrides the project in the key file, optional
 
 	// Cloudinary Configuration
 	CloudinaryCloudName string
@@ -38,10 +67,26 @@ type Config struct {
 	AllowedFileTypes []string
 	UploadPath       string
 	
+	// Client IPs, which rate limits and lockouts are keyed by
+	TrustedProxies  []string // Load balancer IPs or CIDRs whose X-Forwarded-For is believed
+	TrustedPlatform string   // Header the hosting platform sets to the client IP, e.g. CF-Connecting-IP
+
 	// Rate Limiting
 	RateLimitRequests int
 	RateLimitWindow   int // in minutes
+	AuthLockoutStore  string // db or memory
 	
+	// Notifications
+	NotificationRetentionDays int
+	OutboxMaxAttempts         int // Email and SMS send attempts before a message is marked failed
+
+	// Webhooks
+	WebhookMaxAttempts int // Delivery attempts before a webhook is marked failed
+	WebhookTimeout     int // Seconds to wait for a partner's response
+
+	// Kitchen Queue
+	OrderAcceptWindow int // Seconds a restaurant has to accept a new order before it is rejected
+
 	// Delivery Configuration
 	DefaultDeliveryFee float64
 	MaxDeliveryRadius  float64 // in kilometers
@@ -55,6 +100,17 @@ func Load() *Config {
 		JWTSecret:   getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-this-in-production"),
 		Port:        getEnv("PORT", "8080"),
 		
+		// Access Control
+		SuperAdminEmails: getEnvAsList("SUPER_ADMIN_EMAILS"),
+
+		// Password Policy
+		PasswordMinLength:     getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordRequireUpper:  getEnvAsBool("PASSWORD_REQUIRE_UPPERCASE", true),
+		PasswordRequireLower:  getEnvAsBool("PASSWORD_REQUIRE_LOWERCASE", true),
+		PasswordRequireDigit:  getEnvAsBool("PASSWORD_REQUIRE_DIGIT", true),
+		PasswordRequireSymbol: getEnvAsBool("PASSWORD_REQUIRE_SYMBOL", true),
+		PasswordRejectCommon:  getEnvAsBool("PASSWORD_REJECT_COMMON", true),
+
 		// M-Pesa Configuration
 		MpesaConsumerKey:    getEnv("MPESA_CONSUMER_KEY", ""),
 		MpesaConsumerSecret: getEnv("MPESA_CONSUMER_SECRET", ""),
@@ -63,12 +119,28 @@ func Load() *Config {
 		MpesaEnvironment:    getEnv("MPESA_ENVIRONMENT", "sandbox"),
 		
 		// Email Configuration
-		SMTPHost:     getEnv("EMAIL_HOST", "smtp.gmail.com"),
-		SMTPPort:     getEnvAsInt("EMAIL_PORT", 587),
-		SMTPUsername: getEnv("EMAIL_USERNAME", ""),
-		SMTPPassword: getEnv("EMAIL_PASSWORD", ""),
-		EmailFrom:    getEnv("EMAIL_FROM", ""),
-		BackendURL:   getEnv("BACKEND_URL", "http://localhost:8080"),
+		EmailTransport:  getEnv("EMAIL_TRANSPORT", "smtp"),
+		SMTPHost:        getEnv("EMAIL_HOST", "smtp.gmail.com"),
+		SMTPPort:        getEnvAsInt("EMAIL_PORT", 587),
+		SMTPUsername:    getEnv("EMAIL_USERNAME", ""),
+		SMTPPassword:    getEnv("EMAIL_PASSWORD", ""),
+		SMTPSecurity:    getEnv("EMAIL_SECURITY", "starttls"),
+		EmailFrom:       getEnv("EMAIL_FROM", ""),
+		EmailCaptureDir: getEnv("EMAIL_CAPTURE_DIR", "./tmp/mail"),
+		BackendURL:      getEnv("BACKEND_URL", "http://localhost:8080"),
+
+		// SMS Configuration
+		SMSProvider:               getEnv("SMS_PROVIDER", "fake"),
+		SMSSenderID:               getEnv("SMS_SENDER_ID", ""),
+		SMSCallbackToken:          getEnv("SMS_CALLBACK_TOKEN", ""),
+		AfricasTalkingUsername:    getEnv("AFRICASTALKING_USERNAME", "sandbox"),
+		AfricasTalkingAPIKey:      getEnv("AFRICASTALKING_API_KEY", ""),
+		AfricasTalkingEnvironment: getEnv("AFRICASTALKING_ENVIRONMENT", "sandbox"),
+
+		// Push Notification Configuration
+		PushProvider:       getEnv("PUSH_PROVIDER", "fake"),
+		FCMCredentialsFile: getEnv("FCM_CREDENTIALS_FILE", ""),
+		FCMProjectID:       getEnv("FCM_PROJECT_ID", ""),
 
 		// Cloudinary Configuration
 		CloudinaryCloudName: getEnv("CLOUDINARY_CLOUD_NAME", ""),
@@ -81,16 +153,58 @@ func Load() *Config {
 		AllowedFileTypes: []string{"image/jpeg", "image/png", "image/gif"},
 		UploadPath:       getEnv("UPLOAD_PATH", "./uploads"),
 		
+		// Client IPs
+		TrustedProxies:  getEnvAsList("TRUSTED_PROXIES"),
+		TrustedPlatform: getEnv("TRUSTED_PLATFORM", ""),
+
 		// Rate Limiting
 		RateLimitRequests: getEnvAsInt("RATE_LIMIT_REQUESTS", 100),
 		RateLimitWindow:   getEnvAsInt("RATE_LIMIT_WINDOW", 15),
+		AuthLockoutStore:  getEnv("AUTH_LOCKOUT_STORE", "db"),
 		
+		// Notifications
+		NotificationRetentionDays: getEnvAsInt("NOTIFICATION_RETENTION_DAYS", 90),
+		OutboxMaxAttempts:         getEnvAsInt("OUTBOX_MAX_ATTEMPTS", 8),
+
+		// Webhooks
+		WebhookMaxAttempts: getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookTimeout:     getEnvAsInt("WEBHOOK_TIMEOUT", 10),
+
+		// Kitchen Queue
+		OrderAcceptWindow: getEnvAsInt("ORDER_ACCEPT_WINDOW", 300),
+
 		// Delivery Configuration
 		DefaultDeliveryFee: getEnvAsFloat64("DEFAULT_DELIVERY_FEE", 150.0), // KES 150
 		MaxDeliveryRadius:  getEnvAsFloat64("MAX_DELIVERY_RADIUS", 25.0),   // 25km
 	}
 }
 
+// Validate rejects settings that must not reach production, such as development fakes
+// that report messages as sent without sending them
+func (c *Config) Validate() error {
+	if c.Environment != "production" {
+		return nil
+	}
+
+	var problems []error
+	if c.EmailTransport != "smtp" {
+		problems = append(problems, errors.New("EMAIL_TRANSPORT must be smtp in production"))
+	}
+	if c.SMSProvider != "africastalking" || c.AfricasTalkingAPIKey == "" {
+		problems = append(problems, errors.New("SMS_PROVIDER must be africastalking with AFRICASTALKING_API_KEY set in production"))
+	}
+	if c.SMSCallbackToken == "" {
+		problems = append(problems, errors.New("SMS_CALLBACK_TOKEN is required in production"))
+	}
+	if len(c.TrustedProxies) == 0 && c.TrustedPlatform == "" {
+		problems = append(problems, errors.New("TRUSTED_PROXIES or TRUSTED_PLATFORM is required in production"))
+	}
+	if c.PushProvider != "fcm" || c.FCMCredentialsFile == "" {
+		problems = append(problems, errors.New("PUSH_PROVIDER must be fcm with FCM_CREDENTIALS_FILE set in production"))
+	}
+	return errors.Join(problems...)
+}
+
 // getEnv gets an environment variable with a fallback value
 func getEnv(key, fallback string) string {
 	if value := os.Getenv(key); value != "" {
@@ -99,6 +213,17 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvAsList gets a comma-separated environment variable as a lowercased list
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 // getEnvAsInt gets an environment variable as integer with a fallback value
 func getEnvAsInt(key string, fallback int) int {
 	if value := os.Getenv(key); value != "" {
@@ -129,3 +254,12 @@ func getEnvAsFloat64(key string, fallback float64) float64 {
 	return fallback
 }
 
+// getEnvAsBool gets an environment variable as bool with a fallback value
+func getEnvAsBool(key string, fallback bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return fallback
+}
@@ -22,8 +22,28 @@ func Initialize(databaseURL string) (*gorm.DB, error) {
 
 // Migrate runs database migrations
 func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
 		&models.User{},
+		&models.Session{},
+		&models.UserToken{},
+		&models.UserMFA{},
+		&models.MFARecoveryCode{},
+		&models.Role{},
+		&models.RolePermission{},
+		&models.UserRoleAssignment{},
+		&models.KnownPermission{},
+		&models.RestaurantStaff{},
+		&models.OrderAuditLog{},
+		&models.OTPCode{},
+		&models.AuthLockout{},
+		&models.SMSMessage{},
+		&models.DeviceToken{},
+		&models.NotificationPreference{},
+		&models.NotificationSettings{},
+		&models.OutboxMessage{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.APIKey{},
 		&models.Address{},
 		&models.County{},
 		&models.DeliveryZone{},
@@ -37,8 +57,27 @@ func Migrate(db *gorm.DB) error {
 		&models.Payment{},
 		&models.Delivery{},
 		&models.Review{},
+		&models.ReviewVote{},
 		&models.DriverLocation{},
 		&models.Notification{},
-	)
+		&models.PromoCode{},
+		&models.PromoRedemption{},
+	); err != nil {
+		return err
+	}
+
+	if err := migrateSearch(db); err != nil {
+		return err
+	}
+
+	if err := migrateReviews(db); err != nil {
+		return err
+	}
+
+	if err := migrateLegacyTokens(db); err != nil {
+		return err
+	}
+
+	return seedRoles(db)
 }
 
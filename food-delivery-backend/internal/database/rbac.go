@@ -0,0 +1,88 @@
+package database
+
+import (
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// seedRoles creates any missing built-in role with its default permissions. Existing
+// roles are left alone so permission changes made by super admins survive restarts;
+// only permissions that are new to this database are granted to their default roles.
+func seedRoles(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, def := range models.DefaultRoles {
+			var count int64
+			if err := tx.Model(&models.Role{}).Where("name = ?", string(def.Name)).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+
+			role := models.Role{
+				Name:        string(def.Name),
+				Description: def.Description,
+				Scope:       def.Scope,
+				IsSystem:    true,
+			}
+			for _, permission := range def.Permissions {
+				role.Permissions = append(role.Permissions, models.RolePermission{Permission: permission})
+			}
+			if err := tx.Create(&role).Error; err != nil {
+				return err
+			}
+		}
+
+		return grantNewPermissions(tx)
+	})
+}
+
+// grantNewPermissions gives permissions introduced since the last migration to the
+// built-in roles that have them by default, then records them as known
+func grantNewPermissions(tx *gorm.DB) error {
+	var known []models.Permission
+	if err := tx.Model(&models.KnownPermission{}).Pluck("permission", &known).Error; err != nil {
+		return err
+	}
+	seen := make(map[models.Permission]bool, len(known))
+	for _, permission := range known {
+		seen[permission] = true
+	}
+
+	for _, permission := range models.AllPermissions {
+		if seen[permission] {
+			continue
+		}
+
+		for _, def := range models.DefaultRoles {
+			if !hasPermission(def.Permissions, permission) {
+				continue
+			}
+			var role models.Role
+			if err := tx.Where("name = ?", string(def.Name)).First(&role).Error; err != nil {
+				return err
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.RolePermission{RoleID: role.ID, Permission: permission}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&models.KnownPermission{Permission: permission}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasPermission reports whether the list contains the permission
+func hasPermission(permissions []models.Permission, permission models.Permission) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
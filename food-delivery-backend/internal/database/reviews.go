@@ -0,0 +1,23 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// reviewMigrations enforce one review per order target. Postgres treats NULLs as
+// distinct in unique indexes, so the optional menu item and driver are coalesced.
+var reviewMigrations = []string{
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_order_target ON reviews
+		(order_id, user_id, coalesce(menu_item_id, 0), coalesce(driver_id, 0))
+		WHERE order_id IS NOT NULL AND deleted_at IS NULL`,
+}
+
+// migrateReviews creates review constraints that AutoMigrate cannot express
+func migrateReviews(db *gorm.DB) error {
+	for _, statement := range reviewMigrations {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// searchMigrations sets up full-text and trigram search. Postgres ships no Swahili
+// dictionary, so Swahili fields are indexed with the 'simple' configuration.
+var searchMigrations = []string{
+	`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+
+	// Generated tsvector columns
+	`ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+		setweight(to_tsvector('simple', coalesce(county, '')), 'C')
+	) STORED`,
+	`ALTER TABLE menu_items ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(name_swahili, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+		setweight(to_tsvector('simple', coalesce(description_swahili, '')), 'B')
+	) STORED`,
+	`ALTER TABLE cuisines ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(name_swahili, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce(description, '')), 'B')
+	) STORED`,
+
+	// Full-text indexes
+	`CREATE INDEX IF NOT EXISTS idx_restaurants_search_vector ON restaurants USING GIN (search_vector)`,
+	`CREATE INDEX IF NOT EXISTS idx_menu_items_search_vector ON menu_items USING GIN (search_vector)`,
+	`CREATE INDEX IF NOT EXISTS idx_cuisines_search_vector ON cuisines USING GIN (search_vector)`,
+
+	// Trigram indexes for fuzzy matching; the compact variants ignore whitespace so
+	// "nyamachoma" still finds "Nyama Choma"
+	`CREATE INDEX IF NOT EXISTS idx_restaurants_name_trgm ON restaurants USING GIN (lower(name) gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_restaurants_name_compact_trgm ON restaurants USING GIN (regexp_replace(lower(name), '\s+', '', 'g') gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_menu_items_name_trgm ON menu_items USING GIN (lower(name) gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_menu_items_name_compact_trgm ON menu_items USING GIN (regexp_replace(lower(name), '\s+', '', 'g') gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_menu_items_name_swahili_trgm ON menu_items USING GIN (lower(name_swahili) gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_cuisines_name_trgm ON cuisines USING GIN (lower(name) gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_cuisines_name_swahili_trgm ON cuisines USING GIN (lower(name_swahili) gin_trgm_ops)`,
+}
+
+// migrateSearch creates search columns and indexes that AutoMigrate cannot express
+func migrateSearch(db *gorm.DB) error {
+	for _, statement := range searchMigrations {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// legacyTokenMigrations move outstanding plaintext tokens from the users table into
+// user_tokens as SHA-256 hashes, then drop the old columns
+var legacyTokenMigrations = []string{
+	`INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at, created_at)
+		SELECT id, 'email_verification', encode(sha256(convert_to(email_verification_token, 'UTF8')), 'hex'), now() + interval '24 hours', now()
+		FROM users WHERE email_verification_token <> '' AND deleted_at IS NULL
+		ON CONFLICT DO NOTHING`,
+	`INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at, created_at)
+		SELECT id, 'password_reset', encode(sha256(convert_to(password_reset_token, 'UTF8')), 'hex'), password_reset_expires, now()
+		FROM users WHERE password_reset_token <> '' AND password_reset_expires > now() AND deleted_at IS NULL
+		ON CONFLICT DO NOTHING`,
+	`ALTER TABLE users DROP COLUMN IF EXISTS email_verification_token`,
+	`ALTER TABLE users DROP COLUMN IF EXISTS password_reset_token`,
+	`ALTER TABLE users DROP COLUMN IF EXISTS password_reset_expires`,
+}
+
+// migrateLegacyTokens runs legacyTokenMigrations once, while the old columns still exist
+func migrateLegacyTokens(db *gorm.DB) error {
+	if !db.Migrator().HasColumn("users", "email_verification_token") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range legacyTokenMigrations {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"kenyan-food-delivery/internal/models"
+	"kenyan-food-delivery/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateUserStatus activates, deactivates or suspends a user account (admin)
+func (h *Handler) UpdateUserStatus(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	var req services.UpdateUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	user, err := h.services.User.UpdateUserStatus(uint(userID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update user status",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User status updated successfully",
+		"data":    user,
+	})
+}
+
+// ForceLogoutUser revokes every session of a user (admin)
+func (h *Handler) ForceLogoutUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	revoked, err := h.services.Session.RevokeAllSessions(uint(userID), models.SessionRevokedAdmin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to log out user",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User logged out of all sessions",
+		"data": gin.H{
+			"sessions_revoked": revoked,
+		},
+	})
+}
+
+// UnlockUser clears a user's failed login lockout (admin)
+func (h *Handler) UnlockUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	if err := h.services.Lockout.UnlockUser(uint(userID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to unlock user",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User unlocked successfully",
+	})
+}
+
+// GetOutboxMessages lists queued, sent and failed emails and text messages (admin)
+func (h *Handler) GetOutboxMessages(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	status := models.OutboxStatus(c.Query("status"))
+	channel := models.OutboxChannel(c.Query("channel"))
+
+	messages, total, err := h.services.Outbox.List(status, channel, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get outbox messages",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Outbox messages retrieved successfully",
+		"data":    messages,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// ResendOutboxMessage queues a failed email or text message for another round of attempts (admin)
+func (h *Handler) ResendOutboxMessage(c *gin.Context) {
+	messageID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid message ID",
+		})
+		return
+	}
+
+	message, err := h.services.Outbox.Resend(uint(messageID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to resend message",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Message queued for resending",
+		"data":    message,
+	})
+}
+
+// CompleteRefund records that a pending refund has been paid back through the provider (admin)
+func (h *Handler) CompleteRefund(c *gin.Context) {
+	paymentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid payment ID",
+		})
+		return
+	}
+
+	var req services.CompleteRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	payment, err := h.services.Payment.CompleteRefund(uint(paymentID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to complete refund",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Refund completed successfully",
+		"data":    payment,
+	})
+}
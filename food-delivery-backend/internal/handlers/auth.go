@@ -1,13 +1,33 @@
 package handlers
 
 import (
+	"errors"
+	"html"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"kenyan-food-delivery/internal/auth"
+	"kenyan-food-delivery/internal/models"
 	"kenyan-food-delivery/internal/services"
+	"kenyan-food-delivery/internal/templates"
 
 	"github.com/gin-gonic/gin"
 )
 
+// GetPasswordPolicy returns the rules new passwords must meet, for showing in sign-up forms
+func (h *Handler) GetPasswordPolicy(c *gin.Context) {
+	policy := h.services.Auth.PasswordPolicy()
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password policy retrieved successfully",
+		"data": gin.H{
+			"policy":       policy,
+			"requirements": policy.Requirements(),
+		},
+	})
+}
+
 // Register handles user registration
 func (h *Handler) Register(c *gin.Context) {
 	var req services.RegisterRequest
@@ -19,12 +39,9 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
-	response, err := h.services.Auth.Register(&req)
+	response, err := h.services.Auth.Register(&req, deviceInfo(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Registration failed",
-			"message": err.Error(),
-		})
+		respondAuthError(c, http.StatusBadRequest, "Registration failed", err)
 		return
 	}
 
@@ -45,19 +62,13 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	response, err := h.services.Auth.Login(&req)
+	response, challenge, err := h.services.Auth.Login(&req, deviceInfo(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "Login failed",
-			"message": err.Error(),
-		})
+		respondAuthError(c, http.StatusUnauthorized, "Login failed", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"data":    response,
-	})
+	respondLogin(c, response, challenge)
 }
 
 // RefreshToken handles token refresh
@@ -74,7 +85,7 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	response, err := h.services.Auth.RefreshToken(req.RefreshToken)
+	response, err := h.services.Auth.RefreshToken(req.RefreshToken, deviceInfo(c))
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error":   "Token refresh failed",
@@ -89,17 +100,173 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 	})
 }
 
-// Logout handles user logout
+// Logout revokes the current session
 func (h *Handler) Logout(c *gin.Context) {
-	// In a stateless JWT system, logout is typically handled client-side
-	// by removing the token. For enhanced security, you could implement
-	// a token blacklist here.
-	
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	if err := h.services.Session.RevokeSession(userID.(uint), c.GetString("session_id"), models.SessionRevokedLogout); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Logout failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Logged out successfully",
 	})
 }
 
+// LogoutAllDevices revokes every session of the current user, including this one
+func (h *Handler) LogoutAllDevices(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	revoked, err := h.services.Session.RevokeAllSessions(userID.(uint), models.SessionRevokedLogoutAll)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to log out of all devices",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out of all devices successfully",
+		"data": gin.H{
+			"sessions_revoked": revoked,
+		},
+	})
+}
+
+// GetSessions lists the current user's active sessions
+func (h *Handler) GetSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	sessions, err := h.services.Session.GetUserSessions(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get sessions",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	currentSession := c.GetString("session_id")
+	data := make([]gin.H, 0, len(sessions))
+	for _, session := range sessions {
+		data = append(data, gin.H{
+			"session": session,
+			"current": session.FamilyID == currentSession,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sessions retrieved successfully",
+		"data":    data,
+	})
+}
+
+// RevokeSession logs the current user out of one of their other sessions
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	if err := h.services.Session.RevokeSession(userID.(uint), c.Param("id"), models.SessionRevokedLogout); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Failed to revoke session",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Session revoked successfully",
+	})
+}
+
+// respondLogin writes a login result: tokens, or the challenge for a second factor
+func respondLogin(c *gin.Context, response *services.AuthResponse, challenge *services.MFAChallenge) {
+	if challenge != nil {
+		message := "Two-factor authentication required"
+		if challenge.EnrollmentRequired {
+			message = "Two-factor authentication must be set up to continue"
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": message,
+			"data":    challenge,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Login successful",
+		"data":    response,
+	})
+}
+
+// respondAuthError writes an authentication error, turning lockouts into 429 with Retry-After
+// and listing each broken rule when a password is rejected by the policy
+func respondAuthError(c *gin.Context, status int, title string, err error) {
+	var policy *auth.PasswordPolicyError
+	if errors.As(err, &policy) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Password does not meet requirements",
+			"message":    err.Error(),
+			"violations": policy.Violations,
+		})
+		return
+	}
+
+	var lockout *services.LockoutError
+	if errors.As(err, &lockout) {
+		retryAfter := int(math.Ceil(lockout.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many attempts",
+			"message":     err.Error(),
+			"retry_after": retryAfter,
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{
+		"error":   title,
+		"message": err.Error(),
+	})
+}
+
+// deviceInfo describes the client making an authentication request
+func deviceInfo(c *gin.Context) *services.DeviceInfo {
+	return &services.DeviceInfo{
+		DeviceName: c.GetHeader("X-Device-Name"),
+		UserAgent:  c.Request.UserAgent(),
+		IPAddress:  c.ClientIP(),
+	}
+}
+
 // GetProfile gets the current user's profile
 func (h *Handler) GetProfile(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -167,11 +334,13 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	if req.LastName != "" {
 		user.LastName = req.LastName
 	}
-	if req.PhoneNumber != "" {
+	if req.PhoneNumber != "" && req.PhoneNumber != user.PhoneNumber {
+		// A new number has to be verified again
 		user.PhoneNumber = req.PhoneNumber
+		user.PhoneVerifiedAt = nil
 	}
 	if req.PreferredLanguage != "" {
-		user.PreferredLanguage = req.PreferredLanguage
+		user.PreferredLanguage = templates.NormalizeLocale(req.PreferredLanguage)
 	}
 	if req.ProfilePicture != "" {
 		user.ProfilePicture = req.ProfilePicture
@@ -321,11 +490,8 @@ func (h *Handler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.services.Auth.ForgotPassword(&req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to process forgot password request",
-			"message": err.Error(),
-		})
+	if err := h.services.Auth.ForgotPassword(&req, c.ClientIP()); err != nil {
+		respondAuthError(c, http.StatusInternalServerError, "Failed to process forgot password request", err)
 		return
 	}
 
@@ -345,6 +511,14 @@ func (h *Handler) ResetPasswordLink(c *gin.Context) {
 		return
 	}
 
+	// Show the requirements the server will actually enforce
+	policy := h.services.Auth.PasswordPolicy()
+	minLength := strconv.Itoa(policy.MinLength)
+	var requirements strings.Builder
+	for _, requirement := range policy.Requirements() {
+		requirements.WriteString("<li>" + html.EscapeString(requirement) + "</li>")
+	}
+
 	// Return HTML form for password reset
 	htmlResponse := `
 	<!DOCTYPE html>
@@ -466,26 +640,22 @@ func (h *Handler) ResetPasswordLink(c *gin.Context) {
 			</div>
 			
 			<form id="resetPasswordForm" method="POST" action="/api/v1/auth/reset-password">
-				<input type="hidden" name="token" value="` + token + `">
+				<input type="hidden" name="token" value="` + html.EscapeString(token) + `">
 				
 				<div class="form-group">
 					<label for="password">New Password:</label>
-					<input type="password" id="password" name="password" required minlength="8">
+					<input type="password" id="password" name="password" required minlength="` + minLength + `">
 				</div>
 				
 				<div class="form-group">
 					<label for="confirmPassword">Confirm New Password:</label>
-					<input type="password" id="confirmPassword" name="confirmPassword" required minlength="8">
+					<input type="password" id="confirmPassword" name="confirmPassword" required minlength="` + minLength + `">
 				</div>
 				
 				<div class="password-requirements">
 					<strong>Password Requirements:</strong>
 					<ul>
-						<li>At least 8 characters long</li>
-						<li>Contains at least one uppercase letter</li>
-						<li>Contains at least one lowercase letter</li>
-						<li>Contains at least one number</li>
-						<li>Contains at least one special character</li>
+						` + requirements.String() + `
 					</ul>
 				</div>
 				
@@ -518,9 +688,9 @@ func (h *Handler) ResetPasswordLink(c *gin.Context) {
 					return;
 				}
 				
-				// Validate password requirements
-				if (password.length < 8) {
-					errorMessage.textContent = 'Password must be at least 8 characters long';
+				// Validate password requirements; the server checks the rest
+				if (password.length < ` + minLength + `) {
+					errorMessage.textContent = 'Password must be at least ` + minLength + ` characters long';
 					errorMessage.style.display = 'block';
 					return;
 				}
@@ -541,6 +711,9 @@ func (h *Handler) ResetPasswordLink(c *gin.Context) {
 					if (data.message && data.message.includes('successfully')) {
 						successMessage.style.display = 'block';
 						document.getElementById('resetPasswordForm').style.display = 'none';
+					} else if (data.violations) {
+						errorMessage.textContent = data.violations.map(v => v.message).join('. ');
+						errorMessage.style.display = 'block';
 					} else {
 						errorMessage.textContent = data.message || 'An error occurred';
 						errorMessage.style.display = 'block';
@@ -571,11 +744,8 @@ func (h *Handler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.services.Auth.ResetPassword(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Password reset failed",
-			"message": err.Error(),
-		})
+	if err := h.services.Auth.ResetPassword(&req, c.ClientIP()); err != nil {
+		respondAuthError(c, http.StatusBadRequest, "Password reset failed", err)
 		return
 	}
 
@@ -598,16 +768,111 @@ func (h *Handler) ResendVerificationEmail(c *gin.Context) {
 		return
 	}
 
-	if err := h.services.Auth.ResendVerificationEmail(req.Email); err != nil {
+	if err := h.services.Auth.ResendVerificationEmail(req.Email, c.ClientIP()); err != nil {
+		respondAuthError(c, http.StatusBadRequest, "Failed to resend verification email", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Verification email sent successfully",
+	})
+}
+
+
+// RequestPhoneLoginCode sends a login code by SMS
+func (h *Handler) RequestPhoneLoginCode(c *gin.Context) {
+	var req services.PhoneCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Failed to resend verification email",
+			"error":   "Invalid request format",
 			"message": err.Error(),
 		})
 		return
 	}
 
+	if err := h.services.Auth.RequestPhoneLoginCode(&req, c.ClientIP()); err != nil {
+		respondAuthError(c, http.StatusBadRequest, "Failed to send login code", err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Verification email sent successfully",
+		"message": "If an account with that phone number exists, a login code has been sent",
+	})
+}
+
+// PhoneLogin handles passwordless login with a phone number and SMS code
+func (h *Handler) PhoneLogin(c *gin.Context) {
+	var req services.PhoneLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	response, challenge, err := h.services.Auth.LoginWithPhone(&req, deviceInfo(c))
+	if err != nil {
+		respondAuthError(c, http.StatusUnauthorized, "Login failed", err)
+		return
+	}
+
+	respondLogin(c, response, challenge)
+}
+
+// SendPhoneVerificationCode sends a verification code to the current user's phone
+func (h *Handler) SendPhoneVerificationCode(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	if err := h.services.Auth.SendPhoneVerificationCode(userID.(uint)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to send verification code",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Verification code sent successfully",
 	})
 }
 
+// VerifyPhone verifies the current user's phone number with an SMS code
+func (h *Handler) VerifyPhone(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req services.VerifyPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	user, err := h.services.Auth.VerifyPhone(userID.(uint), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Phone verification failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Phone number verified successfully",
+		"data":    user,
+	})
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"kenyan-food-delivery/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// captureTransport returns the email capture transport, responding with 404 when emails are really being sent
+func (h *Handler) captureTransport(c *gin.Context) (*services.CaptureTransport, bool) {
+	capture, ok := h.services.Outbox.EmailTransport().(*services.CaptureTransport)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Email capture is not enabled",
+		})
+		return nil, false
+	}
+	return capture, true
+}
+
+// GetCapturedEmails lists emails captured instead of sent, newest first (development only)
+func (h *Handler) GetCapturedEmails(c *gin.Context) {
+	capture, ok := h.captureTransport(c)
+	if !ok {
+		return
+	}
+
+	messages := capture.Messages()
+	if to := c.Query("to"); to != "" {
+		filtered := messages[:0:0]
+		for _, message := range messages {
+			for _, recipient := range message.To {
+				if recipient == to {
+					filtered = append(filtered, message)
+					break
+				}
+			}
+		}
+		messages = filtered
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Captured emails retrieved successfully",
+		"data":    messages,
+	})
+}
+
+// GetCapturedEmail returns a captured email as a raw .eml message (development only)
+func (h *Handler) GetCapturedEmail(c *gin.Context) {
+	capture, ok := h.captureTransport(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid email ID",
+		})
+		return
+	}
+
+	message, found := capture.Message(uint(id))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Email not found",
+		})
+		return
+	}
+
+	c.Data(http.StatusOK, "message/rfc822", message.Raw())
+}
+
+// ClearCapturedEmails forgets the captured emails (development only)
+func (h *Handler) ClearCapturedEmails(c *gin.Context) {
+	capture, ok := h.captureTransport(c)
+	if !ok {
+		return
+	}
+
+	capture.Reset()
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Captured emails cleared",
+	})
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kenyan-food-delivery/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDevices lists the devices registered for the current user's push notifications
+func (h *Handler) GetDevices(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	devices, err := h.services.Push.GetDevices(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get devices",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Devices retrieved successfully",
+		"data":    devices,
+	})
+}
+
+// RegisterDevice registers a push token for the current user's device
+func (h *Handler) RegisterDevice(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req services.RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	device, err := h.services.Push.RegisterDevice(userID.(uint), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to register device",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Device registered successfully",
+		"data":    device,
+	})
+}
+
+// UnregisterDevice stops push notifications to one of the current user's devices, e.g. on logout
+func (h *Handler) UnregisterDevice(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req services.UnregisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.services.Push.UnregisterDevice(userID.(uint), req.Token); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Failed to unregister device",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Device unregistered successfully",
+	})
+}
@@ -25,3 +25,7 @@ func New(db *gorm.DB, cfg *config.Config) *Handler {
 	}
 }
 
+// Services exposes the service layer for wiring middleware and background jobs
+func (h *Handler) Services() *services.Services {
+	return h.services
+}
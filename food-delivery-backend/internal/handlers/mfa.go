@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kenyan-food-delivery/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyMFA completes a login with an authenticator or recovery code
+func (h *Handler) VerifyMFA(c *gin.Context) {
+	var req services.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.services.Auth.VerifyMFA(&req, deviceInfo(c))
+	if err != nil {
+		respondAuthError(c, http.StatusUnauthorized, "Two-factor verification failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Login successful",
+		"data":    response,
+	})
+}
+
+// StartMFAEnrollment returns a new authenticator secret for a login that requires 2FA
+func (h *Handler) StartMFAEnrollment(c *gin.Context) {
+	var req services.MFAEnrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	setup, err := h.services.Auth.StartMFAEnrollment(&req, deviceInfo(c))
+	if err != nil {
+		respondAuthError(c, http.StatusUnauthorized, "Failed to start two-factor setup", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Scan the QR code with your authenticator app, then confirm with a code",
+		"data":    setup,
+	})
+}
+
+// ConfirmMFAEnrollment enables the new authenticator and completes the login
+func (h *Handler) ConfirmMFAEnrollment(c *gin.Context) {
+	var req services.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.services.Auth.ConfirmMFAEnrollment(&req, deviceInfo(c))
+	if err != nil {
+		respondAuthError(c, http.StatusUnauthorized, "Two-factor setup failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication enabled. Store your recovery codes somewhere safe.",
+		"data":    response,
+	})
+}
+
+// GetMFAStatus returns the current user's two-factor authentication state
+func (h *Handler) GetMFAStatus(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	status, err := h.services.MFA.Status(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get two-factor status",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor status retrieved successfully",
+		"data":    status,
+	})
+}
+
+// SetupMFA creates an authenticator secret for the current user
+func (h *Handler) SetupMFA(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	setup, err := h.services.MFA.Setup(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to set up two-factor authentication",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Scan the QR code with your authenticator app, then confirm with a code",
+		"data":    setup,
+	})
+}
+
+// EnableMFA confirms the authenticator set up by SetupMFA and returns recovery codes
+func (h *Handler) EnableMFA(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req services.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	codes, err := h.services.MFA.Enable(userID.(uint), req.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to enable two-factor authentication",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication enabled. Store your recovery codes somewhere safe.",
+		"data":    gin.H{"recovery_codes": codes},
+	})
+}
+
+// DisableMFA turns two-factor authentication off for the current user
+func (h *Handler) DisableMFA(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req services.DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.services.Auth.DisableMFA(userID.(uint), &req, c.ClientIP()); err != nil {
+		respondAuthError(c, http.StatusBadRequest, "Failed to disable two-factor authentication", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req services.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	codes, err := h.services.Auth.RegenerateRecoveryCodes(userID.(uint), req.Code, c.ClientIP())
+	if err != nil {
+		respondAuthError(c, http.StatusBadRequest, "Failed to regenerate recovery codes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Recovery codes regenerated. Your old codes no longer work.",
+		"data":    gin.H{"recovery_codes": codes},
+	})
+}
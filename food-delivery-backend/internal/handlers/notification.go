@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kenyan-food-delivery/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetNotifications lists the current user's notifications, newest first
+func (h *Handler) GetNotifications(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var query services.NotificationQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	page, err := h.services.Notification.List(userID.(uint), &query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to get notifications",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notifications retrieved successfully",
+		"data":    page,
+	})
+}
+
+// GetUnreadNotificationCount returns how many notifications the current user hasn't read
+func (h *Handler) GetUnreadNotificationCount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	unread, err := h.services.Notification.UnreadCount(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to count notifications",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Unread count retrieved successfully",
+		"data":    gin.H{"unread_count": unread},
+	})
+}
+
+// MarkNotificationRead marks one notification as read
+func (h *Handler) MarkNotificationRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid notification ID",
+		})
+		return
+	}
+
+	notification, err := h.services.Notification.MarkRead(userID.(uint), uint(notificationID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Failed to mark notification as read",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification marked as read",
+		"data":    notification,
+	})
+}
+
+// MarkAllNotificationsRead marks every notification of the current user as read
+func (h *Handler) MarkAllNotificationsRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	updated, err := h.services.Notification.MarkAllRead(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to mark notifications as read",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "All notifications marked as read",
+		"data":    gin.H{"updated": updated},
+	})
+}
+
+// DeleteNotification removes one notification from the current user's inbox
+func (h *Handler) DeleteNotification(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid notification ID",
+		})
+		return
+	}
+
+	if err := h.services.Notification.Delete(userID.(uint), uint(notificationID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Failed to delete notification",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification deleted successfully",
+	})
+}
+
+// GetNotificationPreferences returns which notifications the current user gets on each channel
+func (h *Handler) GetNotificationPreferences(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	preferences, err := h.services.Notification.GetPreferences(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get notification preferences",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification preferences retrieved successfully",
+		"data":    preferences,
+	})
+}
+
+// UpdateNotificationPreferences changes the current user's channel preferences and quiet hours
+func (h *Handler) UpdateNotificationPreferences(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req services.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	preferences, err := h.services.Notification.UpdatePreferences(userID.(uint), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update notification preferences",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification preferences updated successfully",
+		"data":    preferences,
+	})
+}
+
+// UnsubscribeLink shows a confirmation page for an email unsubscribe link (GET). Unsubscribing
+// takes a POST so that mail scanners following links don't unsubscribe people.
+func (h *Handler) UnsubscribeLink(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		unsubscribePage(c, http.StatusBadRequest, "Invalid Link", "This unsubscribe link is incomplete.", "")
+		return
+	}
+
+	form := fmt.Sprintf(`<form method="POST" action="?token=%s"><button type="submit">Unsubscribe</button></form>`,
+		html.EscapeString(token))
+	unsubscribePage(c, http.StatusOK, "Unsubscribe",
+		"Click below to stop receiving these emails. You can turn them back on in the app at any time.", form)
+}
+
+// Unsubscribe turns off the notifications named in an unsubscribe link (POST), from the
+// confirmation page or a mail client's one-click unsubscribe
+func (h *Handler) Unsubscribe(c *gin.Context) {
+	preference, err := h.services.Notification.Unsubscribe(c.Query("token"))
+	if err != nil {
+		unsubscribePage(c, http.StatusBadRequest, "Unsubscribe Failed", err.Error(), "")
+		return
+	}
+
+	kind := strings.ReplaceAll(string(preference.Type), "_", " ")
+	unsubscribePage(c, http.StatusOK, "You're Unsubscribed",
+		fmt.Sprintf("You will no longer receive %s notifications by %s.", kind, preference.Channel), "")
+}
+
+// unsubscribePage renders the simple HTML pages of the unsubscribe flow
+func unsubscribePage(c *gin.Context, status int, title, message, form string) {
+	htmlResponse := fmt.Sprintf(`
+	<!DOCTYPE html>
+	<html lang="en">
+	<head>
+		<meta charset="UTF-8">
+		<meta name="viewport" content="width=device-width, initial-scale=1.0">
+		<title>%s - Kenyan Food Delivery</title>
+		<style>
+			body { font-family: Arial, sans-serif; margin: 0; padding: 20px; background-color: #f8f9fa; display: flex; justify-content: center; align-items: center; min-height: 100vh; }
+			.container { background: white; padding: 40px; border-radius: 8px; box-shadow: 0 2px 10px rgba(0,0,0,0.1); text-align: center; max-width: 500px; }
+			p { color: #666; line-height: 1.5; }
+			button { background-color: #dc3545; color: white; padding: 12px 24px; border: none; border-radius: 5px; font-size: 16px; cursor: pointer; }
+		</style>
+	</head>
+	<body>
+		<div class="container">
+			<h1>%s</h1>
+			<p>%s</p>
+			%s
+			<p style="margin-top: 30px; font-size: 12px; color: #999;">
+				Kenyan Food Delivery | Nairobi, Kenya
+			</p>
+		</div>
+	</body>
+	</html>
+	`, html.EscapeString(title), html.EscapeString(title), html.EscapeString(message), form)
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.String(status, htmlResponse)
+}
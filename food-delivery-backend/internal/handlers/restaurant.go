@@ -1,38 +1,36 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
+
+	"kenyan-food-delivery/internal/middleware"
+	"kenyan-food-delivery/internal/models"
+	"kenyan-food-delivery/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
+// orderStreamHeartbeat is how often an idle kitchen stream is sent a ping
+const orderStreamHeartbeat = 25 * time.Second
+
 // Restaurant handlers
 
-// GetRestaurants gets all restaurants with optional filtering
+// GetRestaurants gets restaurants with optional filtering and sorting
 func (h *Handler) GetRestaurants(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	county := c.Query("county")
-
-	restaurants, total, err := h.services.Restaurant.GetRestaurants(page, limit, county)
+	query, err := services.ParseRestaurantQuery(c.Request.URL.Query())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get restaurants",
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid filter parameters",
 			"message": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Restaurants retrieved successfully",
-		"data":    restaurants,
-		"pagination": gin.H{
-			"page":  page,
-			"limit": limit,
-			"total": total,
-		},
-	})
+	h.listRestaurants(c, query)
 }
 
 // GetRestaurant gets a single restaurant by ID
@@ -86,7 +84,7 @@ func (h *Handler) GetRestaurantMenu(c *gin.Context) {
 	})
 }
 
-// SearchRestaurants searches restaurants
+// SearchRestaurants searches restaurants, dishes and cuisines in English and Swahili
 func (h *Handler) SearchRestaurants(c *gin.Context) {
 	query := c.Query("q")
 	if query == "" {
@@ -99,9 +97,13 @@ func (h *Handler) SearchRestaurants(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	restaurants, total, err := h.services.Restaurant.SearchRestaurants(query, page, limit)
+	results, total, err := h.services.Search.Search(query, page, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrSearchQueryTooShort) || errors.Is(err, services.ErrSearchQueryTooLong) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
 			"error":   "Search failed",
 			"message": err.Error(),
 		})
@@ -110,7 +112,7 @@ func (h *Handler) SearchRestaurants(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Search completed successfully",
-		"data":    restaurants,
+		"data":    results,
 		"pagination": gin.H{
 			"page":  page,
 			"limit": limit,
@@ -121,11 +123,37 @@ func (h *Handler) SearchRestaurants(c *gin.Context) {
 
 // GetRestaurantsByCuisine gets restaurants by cuisine
 func (h *Handler) GetRestaurantsByCuisine(c *gin.Context) {
-	cuisine := c.Param("cuisine")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	query, err := services.ParseRestaurantQuery(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid filter parameters",
+			"message": err.Error(),
+		})
+		return
+	}
+	query.Cuisine = c.Param("cuisine")
+
+	h.listRestaurants(c, query)
+}
+
+// GetRestaurantsByLocation gets restaurants by county
+func (h *Handler) GetRestaurantsByLocation(c *gin.Context) {
+	query, err := services.ParseRestaurantQuery(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid filter parameters",
+			"message": err.Error(),
+		})
+		return
+	}
+	query.County = c.Param("county")
+
+	h.listRestaurants(c, query)
+}
 
-	restaurants, total, err := h.services.Restaurant.GetRestaurantsByCuisine(cuisine, page, limit)
+// listRestaurants runs a listing query and writes the paginated response
+func (h *Handler) listRestaurants(c *gin.Context, query *services.RestaurantQuery) {
+	restaurants, total, err := h.services.Restaurant.GetRestaurants(query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to get restaurants",
@@ -137,31 +165,75 @@ func (h *Handler) GetRestaurantsByCuisine(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Restaurants retrieved successfully",
 		"data":    restaurants,
+		"filters": query.AppliedFilters(),
+		"sort":    query.Sort,
 		"pagination": gin.H{
-			"page":  page,
-			"limit": limit,
+			"page":  query.Page,
+			"limit": query.Limit,
 			"total": total,
 		},
 	})
 }
 
-// GetRestaurantsByLocation gets restaurants by county
-func (h *Handler) GetRestaurantsByLocation(c *gin.Context) {
-	county := c.Param("county")
+// GetNearbyRestaurants gets restaurants that deliver to given coordinates or a saved address
+func (h *Handler) GetNearbyRestaurants(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	restaurants, total, err := h.services.Restaurant.GetRestaurants(page, limit, county)
+	var (
+		restaurants []services.NearbyRestaurant
+		total       int64
+		err         error
+	)
+
+	if addressIDStr := c.Query("address_id"); addressIDStr != "" {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Authentication required to search from a saved address",
+			})
+			return
+		}
+
+		addressID, parseErr := strconv.ParseUint(addressIDStr, 10, 32)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid address ID",
+			})
+			return
+		}
+
+		restaurants, total, err = h.services.Restaurant.GetNearbyRestaurantsForAddress(userID.(uint), uint(addressID), page, limit)
+	} else {
+		lat, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+		lon, lonErr := strconv.ParseFloat(c.Query("lon"), 64)
+		if latErr != nil || lonErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "lat and lon, or address_id, are required",
+			})
+			return
+		}
+
+		restaurants, total, err = h.services.Restaurant.GetNearbyRestaurants(lat, lon, page, limit)
+	}
+
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get restaurants",
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrInvalidCoordinates), errors.Is(err, services.ErrAddressNoCoordinates):
+			status = http.StatusBadRequest
+		case errors.Is(err, services.ErrAddressNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to get nearby restaurants",
 			"message": err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Restaurants retrieved successfully",
+		"message": "Nearby restaurants retrieved successfully",
 		"data":    restaurants,
 		"pagination": gin.H{
 			"page":  page,
@@ -184,15 +256,88 @@ func (h *Handler) UpdateRestaurant(c *gin.Context) {
 	})
 }
 
+// GetRestaurantOrders returns a page of a restaurant's kitchen queue or order history (owner or staff)
 func (h *Handler) GetRestaurantOrders(c *gin.Context) {
-	c.JSON(http.StatusNotImplemented, gin.H{
-		"message": "Restaurant orders endpoint - to be implemented",
+	restaurantID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid restaurant ID",
+		})
+		return
+	}
+
+	var query services.OrderQueueQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 || query.Limit > 100 {
+		query.Limit = 50
+	}
+
+	orders, total, err := h.services.Order.GetOrderQueue(uint(restaurantID), &query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get restaurant orders",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Restaurant orders retrieved successfully",
+		"data":    orders,
+		"pagination": gin.H{
+			"page":  query.Page,
+			"limit": query.Limit,
+			"total": total,
+		},
 	})
 }
 
-func (h *Handler) UpdateOrderStatus(c *gin.Context) {
-	c.JSON(http.StatusNotImplemented, gin.H{
-		"message": "Order status update endpoint - to be implemented",
+// StreamRestaurantOrders sends a restaurant's new and changed orders as server-sent events
+// until the client disconnects (owner or staff). Access is checked again at every heartbeat,
+// and the stream ends once the caller's role, staff membership or API key is revoked.
+func (h *Handler) StreamRestaurantOrders(c *gin.Context) {
+	restaurantID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid restaurant ID",
+		})
+		return
+	}
+
+	events, cancel := h.services.Order.SubscribeOrders(uint(restaurantID))
+	defer cancel()
+
+	// Keep proxies from buffering the stream or closing it while the kitchen is quiet
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	heartbeat := time.NewTicker(orderStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.SSEvent("ready", gin.H{"restaurant_id": restaurantID})
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event := <-events:
+			c.SSEvent(event.Type, event.Order)
+			return true
+		case <-heartbeat.C:
+			if !middleware.StillAuthorized(c, models.PermissionOrdersRead, models.ResourceRestaurant, uint(restaurantID)) {
+				c.SSEvent("revoked", gin.H{"restaurant_id": restaurantID})
+				return false
+			}
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
 	})
 }
 
@@ -214,13 +359,134 @@ func (h *Handler) DeleteMenuItem(c *gin.Context) {
 	})
 }
 
-// Order handlers
-func (h *Handler) CreateOrder(c *gin.Context) {
-	c.JSON(http.StatusNotImplemented, gin.H{
-		"message": "Create order endpoint - to be implemented",
+// SetMenuItemStatus marks a menu item available, unavailable or sold out
+func (h *Handler) SetMenuItemStatus(c *gin.Context) {
+	itemID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid menu item ID",
+		})
+		return
+	}
+
+	var req services.MenuItemStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	item, err := h.services.Restaurant.SetMenuItemStatus(uint(itemID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update menu item",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Menu item updated successfully",
+		"data":    item,
+	})
+}
+
+// GetBusyMode returns a restaurant's busy mode, capacity and active orders (owner or staff)
+func (h *Handler) GetBusyMode(c *gin.Context) {
+	restaurantID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid restaurant ID",
+		})
+		return
+	}
+
+	load, err := h.services.Restaurant.GetRestaurantLoad(uint(restaurantID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Failed to get busy mode",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Busy mode retrieved successfully",
+		"data":    load,
+	})
+}
+
+// SetBusyMode extends prep times or pauses new orders for a while (owner or staff)
+func (h *Handler) SetBusyMode(c *gin.Context) {
+	restaurantID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid restaurant ID",
+		})
+		return
+	}
+
+	var req services.BusyModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	load, err := h.services.Restaurant.SetBusyMode(uint(restaurantID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to set busy mode",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Busy mode updated successfully",
+		"data":    load,
+	})
+}
+
+// SetOrderCapacity sets how many active orders a restaurant takes at once (owner)
+func (h *Handler) SetOrderCapacity(c *gin.Context) {
+	restaurantID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid restaurant ID",
+		})
+		return
+	}
+
+	var req services.OrderCapacityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	load, err := h.services.Restaurant.SetOrderCapacity(uint(restaurantID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to set order capacity",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Order capacity updated successfully",
+		"data":    load,
 	})
 }
 
+// Order handlers
 func (h *Handler) GetUserOrders(c *gin.Context) {
 	c.JSON(http.StatusNotImplemented, gin.H{
 		"message": "Get user orders endpoint - to be implemented",
@@ -338,9 +604,3 @@ func (h *Handler) ApproveRestaurant(c *gin.Context) {
 	})
 }
 
-func (h *Handler) UpdateUserStatus(c *gin.Context) {
-	c.JSON(http.StatusNotImplemented, gin.H{
-		"message": "Update user status endpoint - to be implemented",
-	})
-}
-
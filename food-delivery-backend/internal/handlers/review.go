@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"kenyan-food-delivery/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReviewOrder reviews a delivered order
+func (h *Handler) ReviewOrder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid order ID",
+		})
+		return
+	}
+
+	var req services.OrderReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	reviews, err := h.services.Review.ReviewOrder(userID.(uint), uint(orderID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to submit review",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Review submitted successfully",
+		"data":    reviews,
+	})
+}
+
+// GetRestaurantReviews lists a restaurant's reviews
+func (h *Handler) GetRestaurantReviews(c *gin.Context) {
+	restaurantID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid restaurant ID",
+		})
+		return
+	}
+
+	var menuItemID *uint
+	if menuItemStr := c.Query("menu_item_id"); menuItemStr != "" {
+		id, err := strconv.ParseUint(menuItemStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid menu item ID",
+			})
+			return
+		}
+		value := uint(id)
+		menuItemID = &value
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	reviews, total, err := h.services.Review.GetRestaurantReviews(uint(restaurantID), menuItemID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get reviews",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reviews retrieved successfully",
+		"data":    reviews,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// MarkReviewHelpful records a helpful vote on a review
+func (h *Handler) MarkReviewHelpful(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	reviewID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid review ID",
+		})
+		return
+	}
+
+	review, err := h.services.Review.MarkHelpful(userID.(uint), uint(reviewID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to mark review as helpful",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Review marked as helpful",
+		"data":    review,
+	})
+}
+
+// UnmarkReviewHelpful withdraws a helpful vote on a review
+func (h *Handler) UnmarkReviewHelpful(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	reviewID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid review ID",
+		})
+		return
+	}
+
+	if err := h.services.Review.RemoveHelpful(userID.(uint), uint(reviewID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to remove helpful vote",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Helpful vote removed",
+	})
+}
+
+// UploadReviewPhoto uploads a photo and attaches it to the current user's review
+func (h *Handler) UploadReviewPhoto(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	reviewID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid review ID",
+		})
+		return
+	}
+
+	if h.services.Upload == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Image uploads are not configured",
+		})
+		return
+	}
+
+	file, header, err := c.Request.FormFile("photo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Photo file is required",
+			"message": err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	upload, err := h.services.Upload.UploadImage(file, header, "reviews")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to upload photo",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	review, err := h.services.Review.AddReviewImage(userID.(uint), uint(reviewID), upload.URL)
+	if err != nil {
+		h.services.Upload.DeleteImage(upload.PublicID)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to attach photo",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Photo added successfully",
+		"data":    review,
+	})
+}
+
+// ReplyToReview posts the restaurant's public reply to a review
+func (h *Handler) ReplyToReview(c *gin.Context) {
+	reviewID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid review ID",
+		})
+		return
+	}
+
+	var req services.OwnerReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	review, err := h.services.Review.ReplyToReview(uint(reviewID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to reply to review",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reply posted successfully",
+		"data":    review,
+	})
+}
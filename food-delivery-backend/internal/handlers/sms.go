@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"kenyan-food-delivery/pkg/africastalking"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SMSDeliveryReport handles delivery report callbacks from the SMS provider
+func (h *Handler) SMSDeliveryReport(c *gin.Context) {
+	// Config.Validate requires a token in production; without one, reports are only accepted in development
+	tokenRequired := h.config.SMSCallbackToken != "" || h.config.Environment == "production"
+	if tokenRequired && (h.config.SMSCallbackToken == "" ||
+		subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(h.config.SMSCallbackToken)) != 1) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid callback token",
+		})
+		return
+	}
+
+	var report africastalking.DeliveryReport
+	if err := c.ShouldBind(&report); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.services.SMS.HandleDeliveryReport(&report); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to process delivery report",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Delivery report processed",
+	})
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"kenyan-food-delivery/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InviteStaff invites someone to work at a restaurant by email or phone (owner)
+func (h *Handler) InviteStaff(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	restaurantID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid restaurant ID",
+		})
+		return
+	}
+
+	var req services.InviteStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	staff, err := h.services.Staff.InviteStaff(userID.(uint), uint(restaurantID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to invite staff",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Invitation sent successfully",
+		"data":    staff,
+	})
+}
+
+// GetRestaurantStaff lists a restaurant's staff and pending invitations (owner)
+func (h *Handler) GetRestaurantStaff(c *gin.Context) {
+	restaurantID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid restaurant ID",
+		})
+		return
+	}
+
+	staff, err := h.services.Staff.GetRestaurantStaff(uint(restaurantID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get staff",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Staff retrieved successfully",
+		"data":    staff,
+	})
+}
+
+// UpdateStaffRole changes a staff member's role (owner)
+func (h *Handler) UpdateStaffRole(c *gin.Context) {
+	restaurantID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid restaurant ID",
+		})
+		return
+	}
+
+	staffID, err := strconv.ParseUint(c.Param("staffId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid staff ID",
+		})
+		return
+	}
+
+	var req services.UpdateStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	staff, err := h.services.Staff.UpdateStaffRole(uint(restaurantID), uint(staffID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update staff role",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Staff role updated successfully",
+		"data":    staff,
+	})
+}
+
+// RevokeStaff removes a staff member's access or cancels their invitation (owner)
+func (h *Handler) RevokeStaff(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	restaurantID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid restaurant ID",
+		})
+		return
+	}
+
+	staffID, err := strconv.ParseUint(c.Param("staffId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid staff ID",
+		})
+		return
+	}
+
+	if err := h.services.Staff.RevokeStaff(userID.(uint), uint(restaurantID), uint(staffID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to revoke staff access",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Staff access revoked successfully",
+	})
+}
+
+// GetMyStaffInvitations lists pending invitations sent to the current user
+func (h *Handler) GetMyStaffInvitations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	invitations, err := h.services.Staff.GetMyInvitations(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get invitations",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invitations retrieved successfully",
+		"data":    invitations,
+	})
+}
+
+// AcceptStaffInvitation joins the current user to a restaurant's staff
+func (h *Handler) AcceptStaffInvitation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req services.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	staff, err := h.services.Staff.AcceptInvitation(userID.(uint), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to accept invitation",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invitation accepted successfully",
+		"data":    staff,
+	})
+}
+
+// GetMyStaffRestaurants lists the restaurants the current user works at
+func (h *Handler) GetMyStaffRestaurants(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	memberships, err := h.services.Staff.GetMyRestaurants(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get restaurants",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Restaurants retrieved successfully",
+		"data":    memberships,
+	})
+}
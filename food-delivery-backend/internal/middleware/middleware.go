@@ -7,10 +7,8 @@ import (
 	"time"
 
 	"kenyan-food-delivery/internal/auth"
-	"kenyan-food-delivery/internal/models"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/time/rate"
 )
 
 // CORS middleware for handling cross-origin requests
@@ -18,7 +16,7 @@ func CORS() gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, accept, origin, Cache-Control, X-Requested-With")
 		c.Header("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 
 		if c.Request.Method == "OPTIONS" {
@@ -98,70 +96,33 @@ func AuthRequired() gin.HandlerFunc {
 			return
 		}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("user_role", claims.Role)
-		c.Next()
-	})
-}
-
-// AdminRequired middleware for admin-only routes
-func AdminRequired() gin.HandlerFunc {
-	return gin.HandlerFunc(func(c *gin.Context) {
-		role, exists := c.Get("user_role")
-		if !exists || role != models.RoleAdmin {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Admin access required",
+		if err := auth.ValidateSession(claims); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Session has been revoked",
 			})
 			c.Abort()
 			return
 		}
-		c.Next()
-	})
-}
 
-// RestaurantOwnerRequired middleware for restaurant owner routes
-func RestaurantOwnerRequired() gin.HandlerFunc {
-	return gin.HandlerFunc(func(c *gin.Context) {
-		role, exists := c.Get("user_role")
-		if !exists || (role != models.RoleRestaurantOwner && role != models.RoleAdmin) {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Restaurant owner access required",
-			})
-			c.Abort()
-			return
-		}
-		c.Next()
-	})
-}
-
-// DriverRequired middleware for delivery driver routes
-func DriverRequired() gin.HandlerFunc {
-	return gin.HandlerFunc(func(c *gin.Context) {
-		role, exists := c.Get("user_role")
-		if !exists || (role != models.RoleDeliveryDriver && role != models.RoleAdmin) {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Delivery driver access required",
-			})
-			c.Abort()
-			return
-		}
+		c.Set("user_id", claims.UserID)
+		c.Set("user_role", claims.Role)
+		c.Set("session_id", claims.SessionID)
 		c.Next()
 	})
 }
 
-// RateLimiter middleware for rate limiting
-func RateLimiter(requestsPerMinute int) gin.HandlerFunc {
-	limiter := rate.NewLimiter(rate.Limit(requestsPerMinute), requestsPerMinute)
-	
+// OptionalAuth middleware identifies the user when a valid token is sent, without requiring one
+func OptionalAuth() gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		if !limiter.Allow() {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded",
-			})
-			c.Abort()
-			return
+		authHeader := c.GetHeader("Authorization")
+		if authHeader != "" {
+			tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
+			if claims, err := auth.ValidateToken(tokenString); err == nil && auth.ValidateSession(claims) == nil {
+				c.Set("user_id", claims.UserID)
+				c.Set("user_role", claims.Role)
+				c.Set("session_id", claims.SessionID)
+			}
 		}
 		c.Next()
 	})
 }
-
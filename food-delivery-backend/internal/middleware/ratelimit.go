@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"container/list"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitKeyFunc identifies the client a request is counted against
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// RateLimitPolicy describes how many requests a client may make per window
+type RateLimitPolicy struct {
+	Name     string // Keeps each route group's budget separate
+	Requests int
+	Window   time.Duration
+	KeyBy    RateLimitKeyFunc
+}
+
+// RateLimitPolicies groups the policies applied to the API's route groups
+type RateLimitPolicies struct {
+	Auth     RateLimitPolicy // Strict, per IP, for endpoints that check passwords or codes
+	Browse   RateLimitPolicy // Loose, per IP, for public restaurant browsing
+	Payments RateLimitPolicy // Per user, for payment initiation
+	Drivers  RateLimitPolicy // Per user, loose enough for frequent location updates
+	Default  RateLimitPolicy // Everything else, per API key, user or IP
+}
+
+// NewRateLimitPolicies derives the route group policies from the configured default budget
+func NewRateLimitPolicies(requests int, window time.Duration) RateLimitPolicies {
+	return RateLimitPolicies{
+		Auth:     RateLimitPolicy{Name: "auth", Requests: 20, Window: window, KeyBy: KeyByIP},
+		Browse:   RateLimitPolicy{Name: "browse", Requests: requests * 3, Window: window, KeyBy: KeyByIP},
+		Payments: RateLimitPolicy{Name: "payments", Requests: 20, Window: window, KeyBy: KeyByUser},
+		Drivers:  RateLimitPolicy{Name: "drivers", Requests: requests * 3, Window: window, KeyBy: KeyByUser},
+		Default:  RateLimitPolicy{Name: "default", Requests: requests, Window: window, KeyBy: KeyByClient},
+	}
+}
+
+// KeyByIP counts requests per client IP
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser counts requests per authenticated user, falling back to the client IP
+func KeyByUser(c *gin.Context) string {
+	if userID, exists := c.Get("user_id"); exists {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	return KeyByIP(c)
+}
+
+// KeyByClient counts requests per API key once AuthOrAPIKey has verified it, otherwise per
+// user or IP. The raw header is never used, so random values cannot buy fresh budgets.
+func KeyByClient(c *gin.Context) string {
+	if key, ok := apiKeyFrom(c); ok {
+		return fmt.Sprintf("key:%d", key.ID)
+	}
+	return KeyByUser(c)
+}
+
+// RateLimitStore counts requests per key in fixed windows. A shared implementation
+// (e.g. Redis INCR with EXPIRE) lets several instances enforce one budget.
+type RateLimitStore interface {
+	// Take records a request and returns the count so far in the current window and when it resets
+	Take(key string, window time.Duration) (count int, resetAt time.Time, err error)
+}
+
+// RateLimit rejects clients that exceed the policy and sets RateLimit-* headers on every response
+func RateLimit(store RateLimitStore, policy RateLimitPolicy) gin.HandlerFunc {
+	policyHeader := fmt.Sprintf("%d;w=%d", policy.Requests, int(policy.Window.Seconds()))
+
+	return gin.HandlerFunc(func(c *gin.Context) {
+		key := policy.Name + "|" + policy.KeyBy(c)
+		count, resetAt, err := store.Take(key, policy.Window)
+		if err != nil {
+			// Fail open: a broken limiter store should not take the API down
+			log.Printf("Rate limit store error for %s: %v", key, err)
+			c.Next()
+			return
+		}
+
+		remaining := policy.Requests - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		reset := int(time.Until(resetAt).Seconds() + 0.999)
+		if reset < 0 {
+			reset = 0
+		}
+
+		c.Header("RateLimit-Policy", policyHeader)
+		c.Header("RateLimit-Limit", strconv.Itoa(policy.Requests))
+		c.Header("RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(reset))
+
+		if count > policy.Requests {
+			c.Header("Retry-After", strconv.Itoa(reset))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Rate limit exceeded",
+				"retry_after": reset,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	})
+}
+
+// rateLimitEntry is a counter for one key in MemoryRateLimitStore
+type rateLimitEntry struct {
+	key     string
+	count   int
+	resetAt time.Time
+}
+
+// MemoryRateLimitStore keeps counters in process memory, evicting the least recently used
+// keys beyond its capacity so memory stays bounded under many distinct clients
+type MemoryRateLimitStore struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Front is most recently used
+	entries  map[string]*list.Element
+}
+
+// NewMemoryRateLimitStore creates an in-memory store holding at most capacity keys
+func NewMemoryRateLimitStore(capacity int) *MemoryRateLimitStore {
+	if capacity <= 0 {
+		capacity = 10000
+	}
+	return &MemoryRateLimitStore{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Take records a request for a key
+func (m *MemoryRateLimitStore) Take(key string, window time.Duration) (int, time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if element, ok := m.entries[key]; ok {
+		entry := element.Value.(*rateLimitEntry)
+		if now.After(entry.resetAt) {
+			entry.count = 0
+			entry.resetAt = now.Add(window)
+		}
+		entry.count++
+		m.order.MoveToFront(element)
+		return entry.count, entry.resetAt, nil
+	}
+
+	if m.order.Len() >= m.capacity {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*rateLimitEntry).key)
+	}
+
+	entry := &rateLimitEntry{key: key, count: 1, resetAt: now.Add(window)}
+	m.entries[key] = m.order.PushFront(entry)
+	return entry.count, entry.resetAt, nil
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kenyan-food-delivery/internal/auth"
+	"kenyan-food-delivery/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorizer resolves a user's permissions from their roles
+type Authorizer interface {
+	HasPermission(userID uint, permission models.Permission) (bool, error)
+	HasGlobalPermission(userID uint, permission models.Permission) (bool, error)
+	Authorize(userID uint, permission models.Permission, resourceType models.ResourceType, resourceID uint) (bool, error)
+	AuthorizeAPIKey(key *models.APIKey, permission models.Permission, resourceType models.ResourceType, resourceID uint) (bool, error)
+}
+
+var authorizer Authorizer
+
+// SetAuthorizer sets the authorizer used by the permission middlewares
+func SetAuthorizer(a Authorizer) {
+	authorizer = a
+}
+
+// RequirePermission allows the request if any of the user's roles grants the permission,
+// whatever its scope. It is for actions that do not target an existing resource, such as
+// creating a restaurant or taking deliveries; permissions that scoped roles hold for their
+// own restaurants or orders must use RequireGlobalPermission or RequireResourcePermission.
+// API keys are always refused, as they are only valid for their own restaurant's resources.
+func RequirePermission(permission models.Permission) gin.HandlerFunc {
+	return requirePermission(permission, func(a Authorizer, userID uint) (bool, error) {
+		return a.HasPermission(userID, permission)
+	})
+}
+
+// RequireGlobalPermission allows the request only if one of the user's platform-wide roles
+// grants the permission, as platform administration routes require
+func RequireGlobalPermission(permission models.Permission) gin.HandlerFunc {
+	return requirePermission(permission, func(a Authorizer, userID uint) (bool, error) {
+		return a.HasGlobalPermission(userID, permission)
+	})
+}
+
+// requirePermission refuses API keys and users that check does not allow
+func requirePermission(permission models.Permission, check func(a Authorizer, userID uint) (bool, error)) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "User not authenticated",
+			})
+			c.Abort()
+			return
+		}
+		if _, isKey := apiKeyFrom(c); isKey {
+			forbidden(c, permission)
+			return
+		}
+
+		allowed := false
+		if authorizer != nil {
+			var err error
+			allowed, err = check(authorizer, userID.(uint))
+			if err != nil {
+				log.Printf("Permission check for user %v failed: %v", userID, err)
+			}
+		}
+
+		if !allowed {
+			forbidden(c, permission)
+			return
+		}
+		c.Next()
+	})
+}
+
+// RequireResourcePermission allows the request if the user holds the permission for the
+// resource whose ID is in the named route parameter, e.g. their own restaurant or order.
+// An API key must also have the permission as a scope and belong to the resource's restaurant.
+func RequireResourcePermission(permission models.Permission, resourceType models.ResourceType, param string) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "User not authenticated",
+			})
+			c.Abort()
+			return
+		}
+
+		resourceID, err := strconv.ParseUint(c.Param(param), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid " + string(resourceType) + " ID",
+			})
+			c.Abort()
+			return
+		}
+
+		allowed := false
+		if authorizer != nil {
+			if key, isKey := apiKeyFrom(c); isKey {
+				allowed, err = authorizer.AuthorizeAPIKey(key, permission, resourceType, uint(resourceID))
+			} else {
+				allowed, err = authorizer.Authorize(userID.(uint), permission, resourceType, uint(resourceID))
+			}
+			if err != nil {
+				log.Printf("Permission check for user %v on %s %d failed: %v", userID, resourceType, resourceID, err)
+			}
+		}
+
+		if !allowed {
+			forbidden(c, permission)
+			return
+		}
+		c.Next()
+	})
+}
+
+// StillAuthorized repeats a request's authentication and resource permission check, so
+// long-lived responses such as event streams can end once access is taken away. API keys
+// are looked up again to catch revocation and expiry; user tokens are validated again to
+// catch expiry and revoked sessions.
+func StillAuthorized(c *gin.Context, permission models.Permission, resourceType models.ResourceType, resourceID uint) bool {
+	if authorizer == nil {
+		return false
+	}
+
+	var allowed bool
+	var err error
+	if _, isKey := apiKeyFrom(c); isKey {
+		if apiKeyAuthenticator == nil {
+			return false
+		}
+		key, authErr := apiKeyAuthenticator.AuthenticateAPIKey(c.GetHeader(APIKeyHeader), c.ClientIP())
+		if authErr != nil {
+			return false
+		}
+		allowed, err = authorizer.AuthorizeAPIKey(key, permission, resourceType, resourceID)
+	} else {
+		claims, authErr := auth.ValidateToken(strings.Replace(c.GetHeader("Authorization"), "Bearer ", "", 1))
+		if authErr != nil || auth.ValidateSession(claims) != nil {
+			return false
+		}
+		allowed, err = authorizer.Authorize(claims.UserID, permission, resourceType, resourceID)
+	}
+	if err != nil {
+		log.Printf("Permission recheck on %s %d failed: %v", resourceType, resourceID, err)
+	}
+	return allowed
+}
+
+// forbidden rejects a request that lacks a permission
+func forbidden(c *gin.Context, permission models.Permission) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":      "Permission denied",
+		"permission": permission,
+	})
+	c.Abort()
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// DevicePlatform is the kind of device a push token belongs to
+type DevicePlatform string
+
+const (
+	DevicePlatformAndroid DevicePlatform = "android"
+	DevicePlatformIOS     DevicePlatform = "ios"
+	DevicePlatformWeb     DevicePlatform = "web"
+)
+
+// DeviceToken is a push notification registration token for one of a user's devices
+type DeviceToken struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	UserID     uint           `json:"user_id" gorm:"not null;index"`
+	Token      string         `json:"token" gorm:"not null;uniqueIndex"`
+	Platform   DevicePlatform `json:"platform" gorm:"not null"`
+	DeviceName string         `json:"device_name"`
+	LastSeenAt time.Time      `json:"last_seen_at"` // Last time the app registered the token
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
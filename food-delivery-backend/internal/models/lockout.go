@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// AuthLockout tracks failed authentication attempts for an account or client IP
+type AuthLockout struct {
+	Key           string     `json:"key" gorm:"primaryKey;size:191"` // e.g. account:jane@example.com, ip:102.68.1.20
+	Failures      int        `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
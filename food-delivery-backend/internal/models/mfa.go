@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// UserMFA holds a user's TOTP authenticator enrolment
+type UserMFA struct {
+	UserID           uint       `json:"user_id" gorm:"primaryKey"`
+	SecretCiphertext string     `json:"-" gorm:"not null"` // AES-GCM encrypted base32 secret
+	ConfirmedAt      *time.Time `json:"confirmed_at"`      // Nil until the first code is verified
+	LastUsedStep     int64      `json:"-"`                 // Rejects reuse of an accepted code
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// MFARecoveryCode is a single-use code for signing in without the authenticator
+type MFARecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"not null;index"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
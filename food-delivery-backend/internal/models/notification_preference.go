@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// NotificationChannel is a way of reaching a user
+type NotificationChannel string
+
+const (
+	NotificationChannelInApp NotificationChannel = "in_app"
+	NotificationChannelPush  NotificationChannel = "push"
+	NotificationChannelEmail NotificationChannel = "email"
+	NotificationChannelSMS   NotificationChannel = "sms"
+)
+
+// NotificationPreference records whether a user wants one type of notification on one channel.
+// Missing rows fall back to the defaults for that type.
+type NotificationPreference struct {
+	ID        uint                `json:"id" gorm:"primaryKey"`
+	UserID    uint                `json:"user_id" gorm:"not null;uniqueIndex:idx_notification_preference"`
+	Type      NotificationType    `json:"type" gorm:"not null;size:20;uniqueIndex:idx_notification_preference"`
+	Channel   NotificationChannel `json:"channel" gorm:"not null;size:20;uniqueIndex:idx_notification_preference"`
+	Enabled   bool                `json:"enabled" gorm:"not null"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// NotificationSettings holds a user's quiet hours, during which push and SMS are held back
+type NotificationSettings struct {
+	UserID            uint      `json:"user_id" gorm:"primaryKey"`
+	QuietHoursEnabled bool      `json:"quiet_hours_enabled" gorm:"not null;default:false"`
+	QuietHoursStart   string    `json:"quiet_hours_start" gorm:"size:5"` // HH:MM, East Africa Time
+	QuietHoursEnd     string    `json:"quiet_hours_end" gorm:"size:5"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
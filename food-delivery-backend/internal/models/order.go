@@ -35,13 +35,16 @@ type Order struct {
 	Tax               float64     `json:"tax" gorm:"default:0"`
 	DiscountAmount    float64     `json:"discount_amount" gorm:"default:0"`
 	TotalAmount       float64     `json:"total_amount" gorm:"not null"`
-	PaymentStatus     string      `json:"payment_status" gorm:"default:'pending'"` // pending, paid, failed, refunded
+	PaymentStatus     string      `json:"payment_status" gorm:"default:'pending'"` // pending, paid, failed, refund_pending, refunded
 	PaymentMethod     string      `json:"payment_method"` // mpesa, card, cash
 	SpecialInstructions string    `json:"special_instructions"`
 	EstimatedDeliveryTime *time.Time `json:"estimated_delivery_time"`
 	ActualDeliveryTime    *time.Time `json:"actual_delivery_time"`
 	PrepTime          int         `json:"prep_time"` // in minutes
 	DeliveryTime      int         `json:"delivery_time"` // in minutes
+	AcceptBy          *time.Time  `json:"accept_by" gorm:"index"` // Rejected automatically if still pending after this
+	AcceptedAt        *time.Time  `json:"accepted_at"`
+	ReadyAt           *time.Time  `json:"ready_at"`
 	CancelReason      string      `json:"cancel_reason"`
 	CancelledAt       *time.Time  `json:"cancelled_at"`
 	CancelledBy       *uint       `json:"cancelled_by"` // User ID who cancelled
@@ -80,11 +83,12 @@ type OrderItem struct {
 type PaymentStatus string
 
 const (
-	PaymentStatusPending   PaymentStatus = "pending"
-	PaymentStatusCompleted PaymentStatus = "completed"
-	PaymentStatusFailed    PaymentStatus = "failed"
-	PaymentStatusCancelled PaymentStatus = "cancelled"
-	PaymentStatusRefunded  PaymentStatus = "refunded"
+	PaymentStatusPending       PaymentStatus = "pending"
+	PaymentStatusCompleted     PaymentStatus = "completed"
+	PaymentStatusFailed        PaymentStatus = "failed"
+	PaymentStatusCancelled     PaymentStatus = "cancelled"
+	PaymentStatusRefundPending PaymentStatus = "refund_pending" // Owed back to the payer until finance confirms the provider refund
+	PaymentStatusRefunded      PaymentStatus = "refunded"
 )
 
 // PaymentMethod represents different payment methods
@@ -119,6 +123,7 @@ type Payment struct {
 	RefundedAt          *time.Time    `json:"refunded_at"`
 	RefundAmount        float64       `json:"refund_amount" gorm:"default:0"`
 	RefundReason        string        `json:"refund_reason"`
+	RefundReference     string        `json:"refund_reference"` // Provider transaction that paid the refund back
 	CreatedAt           time.Time     `json:"created_at"`
 	UpdatedAt           time.Time     `json:"updated_at"`
 
@@ -131,6 +136,7 @@ type Payment struct {
 type DeliveryStatus string
 
 const (
+	DeliveryStatusPending    DeliveryStatus = "pending" // Ready for pickup, waiting for a driver
 	DeliveryStatusAssigned   DeliveryStatus = "assigned"
 	DeliveryStatusPickedUp   DeliveryStatus = "picked_up"
 	DeliveryStatusInTransit  DeliveryStatus = "in_transit"
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// OTPPurpose represents what a one-time code may be used for
+type OTPPurpose string
+
+const (
+	OTPPurposePhoneVerification OTPPurpose = "phone_verification"
+	OTPPurposeLogin             OTPPurpose = "login"
+)
+
+// OTPCode represents a one-time numeric code sent by SMS. Only a keyed hash of the code is stored.
+type OTPCode struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	PhoneNumber string     `json:"phone_number" gorm:"not null;index:idx_otp_codes_lookup"` // Normalized +254 form
+	Purpose     OTPPurpose `json:"purpose" gorm:"not null;index:idx_otp_codes_lookup"`
+	UserID      *uint      `json:"user_id" gorm:"index"`
+	CodeHash    string     `json:"-" gorm:"not null"`
+	Attempts    int        `json:"attempts" gorm:"default:0"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	ConsumedAt  *time.Time `json:"consumed_at"` // Set when used or superseded by a newer code
+	CreatedAt   time.Time  `json:"created_at"`
+}
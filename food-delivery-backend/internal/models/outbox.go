@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// OutboxChannel is how an outbox message is delivered
+type OutboxChannel string
+
+const (
+	OutboxChannelEmail OutboxChannel = "email"
+	OutboxChannelSMS   OutboxChannel = "sms"
+)
+
+// OutboxStatus tracks an outbox message through delivery
+type OutboxStatus string
+
+const (
+	OutboxStatusPending OutboxStatus = "pending" // Waiting for its first or next attempt
+	OutboxStatusSending OutboxStatus = "sending" // Claimed by a worker
+	OutboxStatusSent    OutboxStatus = "sent"
+	OutboxStatusFailed  OutboxStatus = "failed" // Gave up after the maximum attempts; resend manually
+)
+
+// OutboxMessage is an email or text message waiting to be sent, or a record of one that was.
+// Bodies may contain sign-in links, so they are cleared once the message is sent and never
+// returned by the API. Sensitive messages carry a link or code that expires: their bodies
+// are also cleared when they fail, and they cannot be resent.
+type OutboxMessage struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	Channel       OutboxChannel `json:"channel" gorm:"not null;size:10"`
+	Recipient     string        `json:"recipient" gorm:"not null"`
+	Subject       string        `json:"subject"`            // Email only
+	Category      string        `json:"category"`           // SMS cost reporting category
+	Headers       string        `json:"-"`                  // Extra email headers as JSON
+	TextBody      string        `json:"-" gorm:"type:text"` // Email plain-text part, or the SMS message
+	HTMLBody      string        `json:"-" gorm:"type:text"` // Email HTML part
+	Sensitive     bool          `json:"sensitive" gorm:"not null;default:false"`
+	Status        OutboxStatus  `json:"status" gorm:"not null;size:10;index:idx_outbox_due,priority:1"`
+	Attempts      int           `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time     `json:"next_attempt_at" gorm:"index:idx_outbox_due,priority:2"`
+	LastError     string        `json:"last_error"`
+	SentAt        *time.Time    `json:"sent_at"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PromoDiscountType represents how a promo code discount is calculated
+type PromoDiscountType string
+
+const (
+	PromoDiscountPercentage PromoDiscountType = "percentage"
+	PromoDiscountFixed      PromoDiscountType = "fixed"
+)
+
+// PromoCode represents a marketing discount code redeemable at checkout
+type PromoCode struct {
+	ID                uint              `json:"id" gorm:"primaryKey"`
+	Code              string            `json:"code" gorm:"uniqueIndex;not null"` // Stored upper-case
+	Description       string            `json:"description"`
+	DiscountType      PromoDiscountType `json:"discount_type" gorm:"not null"`
+	DiscountValue     float64           `json:"discount_value" gorm:"not null"` // Percentage (0-100) or KES amount
+	MinOrderAmount    float64           `json:"min_order_amount" gorm:"default:0"`
+	MaxDiscountAmount float64           `json:"max_discount_amount" gorm:"default:0"` // Cap in KES, 0 means no cap
+	StartsAt          *time.Time        `json:"starts_at"`
+	ExpiresAt         *time.Time        `json:"expires_at"`
+	UsageLimit        int               `json:"usage_limit" gorm:"default:0"`    // Global redemptions, 0 means unlimited
+	PerUserLimit      int               `json:"per_user_limit" gorm:"default:0"` // Redemptions per user, 0 means unlimited
+	UsageCount        int               `json:"usage_count" gorm:"default:0"`
+	FirstOrderOnly    bool              `json:"first_order_only" gorm:"default:false"`
+	IsActive          bool              `json:"is_active" gorm:"default:true"`
+	CreatedBy         *uint             `json:"created_by"` // Admin user ID
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt    `json:"-" gorm:"index"`
+
+	// Scoping - an empty list means the code applies everywhere
+	Restaurants []Restaurant      `json:"restaurants,omitempty" gorm:"many2many:promo_code_restaurants;"`
+	Cuisines    []Cuisine         `json:"cuisines,omitempty" gorm:"many2many:promo_code_cuisines;"`
+	Counties    []County          `json:"counties,omitempty" gorm:"many2many:promo_code_counties;"`
+	Redemptions []PromoRedemption `json:"redemptions,omitempty"`
+}
+
+// PromoRedemption records a promo code applied to an order
+type PromoRedemption struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	PromoCodeID    uint      `json:"promo_code_id" gorm:"not null;index"`
+	UserID         uint      `json:"user_id" gorm:"not null;index"`
+	OrderID        uint      `json:"order_id" gorm:"not null;uniqueIndex"`
+	DiscountAmount float64   `json:"discount_amount" gorm:"not null"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// Relationships
+	PromoCode PromoCode `json:"promo_code,omitempty"`
+	User      User      `json:"user,omitempty"`
+	Order     Order     `json:"order,omitempty"`
+}
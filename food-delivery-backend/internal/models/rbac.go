@@ -0,0 +1,204 @@
+package models
+
+import (
+	"time"
+)
+
+// Permission names an action, in resource:action form
+type Permission string
+
+const (
+	PermissionAll Permission = "*" // Granted only to super admins
+
+	PermissionRolesManage        Permission = "roles:manage"
+	PermissionUsersRead          Permission = "users:read"
+	PermissionUsersManage        Permission = "users:manage"
+	PermissionUsersUnlock        Permission = "users:unlock"
+	PermissionStatsRead          Permission = "stats:read"
+	PermissionRestaurantsCreate  Permission = "restaurants:create"
+	PermissionRestaurantsRead    Permission = "restaurants:read"
+	PermissionRestaurantsUpdate  Permission = "restaurants:update"
+	PermissionRestaurantsApprove Permission = "restaurants:approve"
+	PermissionMenuWrite          Permission = "menu:write"
+	PermissionMenuToggle         Permission = "menu:toggle" // Mark items available or sold out
+	PermissionStaffManage        Permission = "staff:manage"
+	PermissionOrdersRead         Permission = "orders:read"
+	PermissionOrdersUpdateStatus Permission = "orders:update_status"
+	PermissionOrdersCancel       Permission = "orders:cancel"
+	PermissionOrdersRefund       Permission = "orders:refund"
+	PermissionOrdersThrottle     Permission = "orders:throttle" // Busy mode and pausing new orders
+	PermissionPaymentsRead       Permission = "payments:read"
+	PermissionReviewsReply       Permission = "reviews:reply"
+	PermissionPromosManage       Permission = "promos:manage"
+	PermissionDeliveriesFulfil   Permission = "deliveries:fulfil"
+	PermissionMessagesManage     Permission = "messages:manage" // View and resend outgoing email and SMS
+	PermissionWebhooksManage     Permission = "webhooks:manage"
+	PermissionAPIKeysManage      Permission = "api_keys:manage"
+)
+
+// AllPermissions is the catalogue of permissions that can be granted to a role
+var AllPermissions = []Permission{
+	PermissionRolesManage,
+	PermissionUsersRead,
+	PermissionUsersManage,
+	PermissionUsersUnlock,
+	PermissionStatsRead,
+	PermissionRestaurantsCreate,
+	PermissionRestaurantsRead,
+	PermissionRestaurantsUpdate,
+	PermissionRestaurantsApprove,
+	PermissionMenuWrite,
+	PermissionMenuToggle,
+	PermissionStaffManage,
+	PermissionOrdersRead,
+	PermissionOrdersUpdateStatus,
+	PermissionOrdersCancel,
+	PermissionOrdersRefund,
+	PermissionOrdersThrottle,
+	PermissionPaymentsRead,
+	PermissionReviewsReply,
+	PermissionPromosManage,
+	PermissionDeliveriesFulfil,
+	PermissionMessagesManage,
+	PermissionWebhooksManage,
+	PermissionAPIKeysManage,
+}
+
+// RoleScope limits which resources a role's permissions apply to
+type RoleScope string
+
+const (
+	RoleScopeGlobal     RoleScope = "global"     // Any resource, e.g. admins and support agents
+	RoleScopeRestaurant RoleScope = "restaurant" // Only restaurants the user owns or works at
+	RoleScopeSelf       RoleScope = "self"       // Only the user's own orders and deliveries
+)
+
+// Role is a named set of permissions; the built-in roles share their names with UserRole
+type Role struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"uniqueIndex;not null;size:64"`
+	Description string    `json:"description"`
+	Scope       RoleScope `json:"scope" gorm:"not null;default:'global'"`
+	IsSystem    bool      `json:"is_system" gorm:"default:false"` // Built-in roles cannot be deleted
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relationships
+	Permissions []RolePermission `json:"permissions,omitempty"`
+}
+
+// RolePermission grants one permission to a role
+type RolePermission struct {
+	RoleID     uint       `json:"-" gorm:"primaryKey"`
+	Permission Permission `json:"permission" gorm:"primaryKey;size:64"`
+	CreatedAt  time.Time  `json:"-"`
+}
+
+// UserRoleAssignment gives a user an additional role on top of User.Role
+type UserRoleAssignment struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_role_assignment"`
+	RoleID     uint      `json:"role_id" gorm:"not null;uniqueIndex:idx_user_role_assignment"`
+	AssignedBy *uint     `json:"assigned_by"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// Relationships
+	Role Role `json:"role,omitempty"`
+}
+
+// KnownPermission records that a permission has been seen by a migration, so a permission
+// added in a later release is granted to the built-in roles exactly once
+type KnownPermission struct {
+	Permission Permission `json:"permission" gorm:"primaryKey;size:64"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// DefaultRole describes a built-in role seeded on first migration
+type DefaultRole struct {
+	Name        UserRole
+	Description string
+	Scope       RoleScope
+	Permissions []Permission
+}
+
+// DefaultRoles are created with these permissions if missing; super admins may edit them afterwards
+var DefaultRoles = []DefaultRole{
+	{
+		Name:        RoleSuperAdmin,
+		Description: "Full access, including role management",
+		Scope:       RoleScopeGlobal,
+		Permissions: []Permission{PermissionAll},
+	},
+	{
+		Name:        RoleAdmin,
+		Description: "Platform operations",
+		Scope:       RoleScopeGlobal,
+		Permissions: []Permission{
+			PermissionUsersRead, PermissionUsersManage, PermissionUsersUnlock, PermissionStatsRead,
+			PermissionRestaurantsRead, PermissionRestaurantsUpdate, PermissionRestaurantsApprove, PermissionMenuWrite,
+			PermissionMenuToggle, PermissionStaffManage,
+			PermissionOrdersRead, PermissionOrdersUpdateStatus, PermissionOrdersCancel, PermissionOrdersRefund,
+			PermissionOrdersThrottle, PermissionPaymentsRead, PermissionPromosManage, PermissionMessagesManage,
+			PermissionWebhooksManage, PermissionAPIKeysManage,
+			PermissionDeliveriesFulfil, // Admins have always been able to use the driver endpoints
+		},
+	},
+	{
+		Name:        RoleSupport,
+		Description: "Customer support agent",
+		Scope:       RoleScopeGlobal,
+		Permissions: []Permission{
+			PermissionUsersRead, PermissionUsersUnlock, PermissionRestaurantsRead,
+			PermissionOrdersRead, PermissionOrdersCancel, PermissionPaymentsRead, PermissionMessagesManage,
+		},
+	},
+	{
+		Name:        RoleFinance,
+		Description: "Payments, refunds and reporting",
+		Scope:       RoleScopeGlobal,
+		Permissions: []Permission{
+			PermissionStatsRead, PermissionOrdersRead, PermissionOrdersRefund, PermissionPaymentsRead,
+		},
+	},
+	{
+		Name:        RoleRestaurantOwner,
+		Description: "Owns and manages restaurants",
+		Scope:       RoleScopeRestaurant,
+		Permissions: []Permission{
+			PermissionRestaurantsCreate, PermissionRestaurantsUpdate, PermissionMenuWrite, PermissionMenuToggle,
+			PermissionStaffManage, PermissionOrdersRead, PermissionOrdersUpdateStatus, PermissionOrdersThrottle,
+			PermissionReviewsReply, PermissionWebhooksManage, PermissionAPIKeysManage,
+		},
+	},
+	{
+		Name:        RoleRestaurantStaff,
+		Description: "Works at a restaurant without owning it; narrowed further by the staff role at each restaurant",
+		Scope:       RoleScopeRestaurant,
+		Permissions: []Permission{
+			PermissionMenuWrite, PermissionMenuToggle, PermissionOrdersRead, PermissionOrdersUpdateStatus,
+			PermissionOrdersThrottle,
+		},
+	},
+	{
+		Name:        RoleDeliveryDriver,
+		Description: "Delivers orders",
+		Scope:       RoleScopeSelf,
+		Permissions: []Permission{PermissionDeliveriesFulfil, PermissionOrdersRead},
+	},
+	{
+		Name:        RoleCustomer,
+		Description: "Places orders",
+		Scope:       RoleScopeSelf,
+		Permissions: []Permission{PermissionOrdersRead, PermissionOrdersCancel},
+	},
+}
+
+// ResourceType names a kind of resource that permissions can be checked against
+type ResourceType string
+
+const (
+	ResourceRestaurant ResourceType = "restaurant"
+	ResourceMenuItem   ResourceType = "menu_item"
+	ResourceOrder      ResourceType = "order"
+	ResourceReview     ResourceType = "review"
+)
@@ -28,8 +28,9 @@ type Restaurant struct {
 	County          string           `json:"county" gorm:"not null"`
 	SubCounty       string           `json:"sub_county"`
 	Ward            string           `json:"ward"`
-	Latitude        float64          `json:"latitude"`
-	Longitude       float64          `json:"longitude"`
+	Latitude        float64          `json:"latitude" gorm:"index:idx_restaurants_location"`
+	Longitude       float64          `json:"longitude" gorm:"index:idx_restaurants_location"`
+	DeliveryRadius  float64          `json:"delivery_radius" gorm:"default:0"` // in kilometers, 0 uses the platform maximum
 	CoverImage      string           `json:"cover_image"`
 	Logo            string           `json:"logo"`
 	Status          RestaurantStatus `json:"status" gorm:"default:'pending'"`
@@ -37,8 +38,13 @@ type Restaurant struct {
 	OpeningTime     string           `json:"opening_time"` // e.g., "08:00"
 	ClosingTime     string           `json:"closing_time"` // e.g., "22:00"
 	DeliveryTime    int              `json:"delivery_time"` // Average delivery time in minutes
+	OrderCapacity   int              `json:"order_capacity" gorm:"default:0"` // Most orders the kitchen works on at once, 0 for no limit
+	BusyUntil       *time.Time       `json:"busy_until"` // Prep estimates are extended until then
+	BusyExtraMinutes int             `json:"busy_extra_minutes" gorm:"default:0"`
+	OrdersPausedUntil *time.Time     `json:"orders_paused_until"` // No new orders until then
 	MinOrderAmount  float64          `json:"min_order_amount"`
 	DeliveryFee     float64          `json:"delivery_fee"`
+	FreeDelivery    bool             `json:"free_delivery" gorm:"default:false"`
 	Rating          float64          `json:"rating" gorm:"default:0"`
 	TotalReviews    int              `json:"total_reviews" gorm:"default:0"`
 	TotalOrders     int              `json:"total_orders" gorm:"default:0"`
@@ -50,6 +56,8 @@ type Restaurant struct {
 	UpdatedAt       time.Time        `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt   `json:"-" gorm:"index"`
 
+	Availability *RestaurantAvailability `json:"availability,omitempty" gorm:"-"` // Set on listings
+
 	// Relationships
 	Owner      User                   `json:"owner,omitempty"`
 	MenuItems  []MenuItem             `json:"menu_items,omitempty"`
@@ -59,6 +67,25 @@ type Restaurant struct {
 	Cuisines   []Cuisine              `json:"cuisines,omitempty" gorm:"many2many:restaurant_cuisine_mappings;"`
 }
 
+// AvailabilityStatus says whether a restaurant is taking orders and how quickly
+type AvailabilityStatus string
+
+const (
+	AvailabilityOpen       AvailabilityStatus = "open"
+	AvailabilityBusy       AvailabilityStatus = "busy"        // Taking orders with longer prep times
+	AvailabilityAtCapacity AvailabilityStatus = "at_capacity" // Too many active orders; resumes as they are finished
+	AvailabilityPaused     AvailabilityStatus = "paused"
+	AvailabilityClosed     AvailabilityStatus = "closed"
+)
+
+// RestaurantAvailability is a restaurant's busy mode and order throttling as customers see it
+type RestaurantAvailability struct {
+	Status           AvailabilityStatus `json:"status"`
+	AcceptingOrders  bool               `json:"accepting_orders"`
+	ExtraPrepMinutes int                `json:"extra_prep_minutes"`
+	ResumesAt        *time.Time         `json:"resumes_at,omitempty"` // When busy mode or a pause ends
+}
+
 // Cuisine represents different types of cuisine (Kenyan context)
 type Cuisine struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
@@ -172,6 +199,9 @@ type Review struct {
 	Images       string         `json:"images"` // JSON array of image URLs
 	IsVerified   bool           `json:"is_verified" gorm:"default:false"`
 	IsHelpful    int            `json:"is_helpful" gorm:"default:0"` // Helpful votes count
+	DriverID     *uint          `json:"driver_id"` // Set for delivery driver reviews
+	OwnerReply   string         `json:"owner_reply"` // Public reply from the restaurant owner
+	OwnerRepliedAt *time.Time   `json:"owner_replied_at"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
@@ -181,5 +211,18 @@ type Review struct {
 	Restaurant *Restaurant `json:"restaurant,omitempty"`
 	MenuItem   *MenuItem  `json:"menu_item,omitempty"`
 	Order      *Order     `json:"order,omitempty"`
+	Driver     *User      `json:"driver,omitempty"`
+}
+
+// ReviewVote records a user marking a review as helpful
+type ReviewVote struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ReviewID  uint      `json:"review_id" gorm:"not null;uniqueIndex:idx_review_votes_review_user"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_review_votes_review_user"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relationships
+	Review Review `json:"review,omitempty"`
+	User   User   `json:"user,omitempty"`
 }
 
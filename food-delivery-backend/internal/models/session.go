@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// Session revocation reasons
+const (
+	SessionRevokedLogout        = "logout"
+	SessionRevokedLogoutAll     = "logout_all"
+	SessionRevokedAdmin         = "admin"
+	SessionRevokedTokenReuse    = "refresh_token_reuse"
+	SessionRevokedPasswordReset = "password_reset"
+	SessionRevokedAccountStatus = "account_status"
+)
+
+// Session represents one issued refresh token. Each rotation adds a new row to the
+// same family; access tokens carry the family ID so the whole login can be revoked.
+type Session struct {
+	ID            string     `json:"-" gorm:"primaryKey;size:64"` // Refresh token jti
+	FamilyID      string     `json:"id" gorm:"not null;index;size:64"`
+	UserID        uint       `json:"user_id" gorm:"not null;index"`
+	DeviceName    string     `json:"device_name"`
+	UserAgent     string     `json:"user_agent"`
+	IPAddress     string     `json:"ip_address"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"not null"`
+	LastUsedAt    time.Time  `json:"last_used_at"`
+	RotatedAt     *time.Time `json:"-"` // Set once the refresh token has been exchanged
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" gorm:"index"`
+	RevokedReason string     `json:"revoked_reason,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Relationships
+	User User `json:"-"`
+}
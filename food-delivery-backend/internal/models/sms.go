@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// SMSStatus represents the delivery state of an outgoing text message
+type SMSStatus string
+
+const (
+	SMSStatusQueued    SMSStatus = "queued"
+	SMSStatusSent      SMSStatus = "sent"
+	SMSStatusDelivered SMSStatus = "delivered"
+	SMSStatusFailed    SMSStatus = "failed"
+)
+
+// SMSMessage logs an outgoing text message, its cost and delivery state.
+// Message bodies are not stored because they may contain one-time codes.
+type SMSMessage struct {
+	ID                uint       `json:"id" gorm:"primaryKey"`
+	Provider          string     `json:"provider" gorm:"not null"`
+	ProviderMessageID string     `json:"provider_message_id" gorm:"index"`
+	PhoneNumber       string     `json:"phone_number" gorm:"not null;index"`
+	Category          string     `json:"category"` // e.g. otp, order_update
+	Status            SMSStatus  `json:"status" gorm:"not null"`
+	ProviderStatus    string     `json:"provider_status"`
+	FailureReason     string     `json:"failure_reason"`
+	NetworkCode       string     `json:"network_code"`
+	Currency          string     `json:"currency"`
+	Cost              float64    `json:"cost"`
+	DeliveredAt       *time.Time `json:"delivered_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
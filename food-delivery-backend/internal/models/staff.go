@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+)
+
+// StaffRole is a staff member's job at one restaurant
+type StaffRole string
+
+const (
+	StaffRoleManager StaffRole = "manager"
+	StaffRoleCashier StaffRole = "cashier"
+	StaffRoleKitchen StaffRole = "kitchen"
+)
+
+// StaffRolePermissions limits what each staff role may do at its restaurant. They are
+// further capped by the permissions of the restaurant_staff role.
+var StaffRolePermissions = map[StaffRole][]Permission{
+	StaffRoleManager: {PermissionOrdersRead, PermissionOrdersUpdateStatus, PermissionOrdersThrottle, PermissionMenuWrite, PermissionMenuToggle},
+	StaffRoleCashier: {PermissionOrdersRead, PermissionOrdersUpdateStatus},
+	StaffRoleKitchen: {PermissionOrdersRead, PermissionOrdersUpdateStatus, PermissionOrdersThrottle, PermissionMenuToggle},
+}
+
+// StaffStatus represents where a staff membership is in its lifecycle
+type StaffStatus string
+
+const (
+	StaffStatusInvited StaffStatus = "invited"
+	StaffStatusActive  StaffStatus = "active"
+	StaffStatusRevoked StaffStatus = "revoked"
+)
+
+// RestaurantStaff gives a user access to one restaurant without owning it
+type RestaurantStaff struct {
+	ID              uint        `json:"id" gorm:"primaryKey"`
+	RestaurantID    uint        `json:"restaurant_id" gorm:"not null;index"`
+	UserID          *uint       `json:"user_id" gorm:"index"` // Set once the invitation is accepted
+	Role            StaffRole   `json:"role" gorm:"not null"`
+	Status          StaffStatus `json:"status" gorm:"not null;default:'invited';index"`
+	InvitedEmail    string      `json:"invited_email"`
+	InvitedPhone    string      `json:"invited_phone"` // +254 format
+	InviteCodeHash  string      `json:"-" gorm:"index"`
+	InviteExpiresAt *time.Time  `json:"invite_expires_at"`
+	InvitedBy       uint        `json:"invited_by"`
+	AcceptedAt      *time.Time  `json:"accepted_at"`
+	RevokedAt       *time.Time  `json:"revoked_at"`
+	RevokedBy       *uint       `json:"revoked_by"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+
+	// Relationships
+	Restaurant *Restaurant `json:"restaurant,omitempty"`
+	User       *User       `json:"user,omitempty"`
+}
+
+// Grants reports whether the staff role allows the permission
+func (s *RestaurantStaff) Grants(permission Permission) bool {
+	for _, p := range StaffRolePermissions[s.Role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderAuditLog records who changed an order and how
+type OrderAuditLog struct {
+	ID           uint        `json:"id" gorm:"primaryKey"`
+	OrderID      uint        `json:"order_id" gorm:"not null;index"`
+	RestaurantID uint        `json:"restaurant_id" gorm:"not null;index"`
+	ActorID      *uint       `json:"actor_id"`   // Empty for automatic changes by the system
+	ActorRole    string      `json:"actor_role"` // owner, manager, cashier, kitchen, or the user's platform role
+	StaffID      *uint       `json:"staff_id"`   // Set when the change was made by restaurant staff
+	Action       string      `json:"action" gorm:"not null"`
+	FromStatus   OrderStatus `json:"from_status"`
+	ToStatus     OrderStatus `json:"to_status"`
+	Note         string      `json:"note"`
+	CreatedAt    time.Time   `json:"created_at"`
+
+	// Relationships
+	Actor *User `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+}
+
+// Order audit actions
+const (
+	OrderAuditStatusChanged = "status_changed"
+)
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// TokenPurpose represents what an emailed token may be used for
+type TokenPurpose string
+
+const (
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+)
+
+// UserToken is a single-use token sent to a user by email. Only a SHA-256 hash of the token is stored.
+type UserToken struct {
+	ID         uint         `json:"id" gorm:"primaryKey"`
+	UserID     uint         `json:"user_id" gorm:"not null;index:idx_user_tokens_user_purpose"`
+	Purpose    TokenPurpose `json:"purpose" gorm:"not null;index:idx_user_tokens_user_purpose"`
+	TokenHash  string       `json:"-" gorm:"not null;uniqueIndex;size:64"`
+	RequestIP  string       `json:"request_ip"` // Client that caused the token to be sent
+	ExpiresAt  time.Time    `json:"expires_at" gorm:"not null;index"`
+	ConsumedAt *time.Time   `json:"consumed_at"` // Set when used or invalidated by a sibling being used
+	CreatedAt  time.Time    `json:"created_at"`
+}
@@ -14,6 +14,10 @@ const (
 	RoleRestaurantOwner  UserRole = "restaurant_owner"
 	RoleDeliveryDriver   UserRole = "delivery_driver"
 	RoleAdmin            UserRole = "admin"
+	RoleSuperAdmin       UserRole = "super_admin"
+	RoleSupport          UserRole = "support"
+	RoleFinance          UserRole = "finance"
+	RoleRestaurantStaff  UserRole = "restaurant_staff"
 )
 
 // UserStatus represents the status of a user account
@@ -26,6 +30,18 @@ const (
 	StatusPending   UserStatus = "pending"
 )
 
+// NotificationType groups notifications by what they are about
+type NotificationType string
+
+const (
+	NotificationTypeOrder     NotificationType = "order"
+	NotificationTypePayment   NotificationType = "payment"
+	NotificationTypeDelivery  NotificationType = "delivery"
+	NotificationTypePromotion NotificationType = "promotion"
+	NotificationTypeSystem    NotificationType = "system"
+	NotificationTypeSecurity  NotificationType = "security" // Always delivered, can't be turned off
+)
+
 // User represents a user in the system
 type User struct {
 	ID                uint           `json:"id" gorm:"primaryKey"`
@@ -43,10 +59,8 @@ type User struct {
 	IsVerified        bool           `json:"is_verified" gorm:"default:false"`
 	EmailVerifiedAt   *time.Time     `json:"email_verified_at"`
 	PhoneVerifiedAt   *time.Time     `json:"phone_verified_at"`
+	TwoFactorEnabled  bool           `json:"two_factor_enabled" gorm:"default:false"`
 	LastLoginAt       *time.Time     `json:"last_login_at"`
-	EmailVerificationToken string    `json:"-"` // Token for email verification
-	PasswordResetToken     string    `json:"-"` // Token for password reset
-	PasswordResetExpires   *time.Time `json:"-"` // When password reset token expires
 	CreatedAt         time.Time      `json:"created_at"`
 	UpdatedAt         time.Time      `json:"updated_at"`
 	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
@@ -139,10 +153,10 @@ type DriverLocation struct {
 // Notification represents user notifications
 type Notification struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
-	UserID    uint           `json:"user_id" gorm:"not null"`
+	UserID    uint           `json:"user_id" gorm:"not null;index"`
 	Title     string         `json:"title" gorm:"not null"`
 	Message   string         `json:"message" gorm:"not null"`
-	Type      string         `json:"type" gorm:"not null"` // order, payment, promotion, system
+	Type      NotificationType `json:"type" gorm:"not null"`
 	Data      string         `json:"data"` // JSON data for additional context
 	IsRead    bool           `json:"is_read" gorm:"default:false"`
 	ReadAt    *time.Time     `json:"read_at"`
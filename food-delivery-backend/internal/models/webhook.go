@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+)
+
+// WebhookEvent names an event partners can subscribe to
+type WebhookEvent string
+
+const (
+	WebhookEventOrderCreated       WebhookEvent = "order.created"
+	WebhookEventOrderStatusChanged WebhookEvent = "order.status_changed"
+
+	// Not offered to subscribers until the M-Pesa callback confirms payments
+	WebhookEventPaymentCompleted WebhookEvent = "payment.completed"
+)
+
+// WebhookEvents lists the events that can be subscribed to
+var WebhookEvents = []WebhookEvent{
+	WebhookEventOrderCreated,
+	WebhookEventOrderStatusChanged,
+}
+
+// WebhookSubscription sends a restaurant's events to a partner system such as a POS
+type WebhookSubscription struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	RestaurantID uint           `json:"restaurant_id" gorm:"not null;index"`
+	URL          string         `json:"url" gorm:"not null"`
+	Secret       string         `json:"-" gorm:"not null"` // Signs each delivery; shown once when created
+	Events       []WebhookEvent `json:"events" gorm:"serializer:json;not null"`
+	Description  string         `json:"description"`
+	IsActive     bool           `json:"is_active" gorm:"not null;default:true"`
+	CreatedBy    uint           `json:"created_by"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// Subscribes reports whether the subscription wants the event
+func (w *WebhookSubscription) Subscribes(event WebhookEvent) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus represents where a delivery is in its lifecycle
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending    WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivering WebhookDeliveryStatus = "delivering"
+	WebhookDeliverySucceeded  WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed     WebhookDeliveryStatus = "failed" // Gave up after the maximum attempts
+)
+
+// WebhookDelivery is one event sent to one subscription, with the outcome of its latest attempt
+type WebhookDelivery struct {
+	ID             uint                  `json:"id" gorm:"primaryKey"`
+	SubscriptionID uint                  `json:"subscription_id" gorm:"not null;index"`
+	RestaurantID   uint                  `json:"restaurant_id" gorm:"not null;index"`
+	EventID        string                `json:"event_id" gorm:"not null;index;size:64"` // Shared by redeliveries, so receivers can ignore duplicates
+	Event          WebhookEvent          `json:"event" gorm:"not null;size:64"`
+	Payload        string                `json:"payload" gorm:"type:text"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"not null;size:16;index:idx_webhook_delivery_due,priority:1"`
+	Attempts       int                   `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at" gorm:"index:idx_webhook_delivery_due,priority:2"`
+	ResponseStatus int                   `json:"response_status"`
+	ResponseBody   string                `json:"response_body"` // Truncated
+	LastError      string                `json:"last_error"`
+	DurationMs     int64                 `json:"duration_ms"`
+	DeliveredAt    *time.Time            `json:"delivered_at"`
+	RedeliveryOf   *uint                 `json:"redelivery_of"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"kenyan-food-delivery/internal/auth"
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyUsageInterval limits how often a key's last-used time is written
+const apiKeyUsageInterval = time.Minute
+
+// ErrInvalidAPIKey is returned for unknown, revoked and expired keys alike
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// APIKeyService issues, authenticates and revokes restaurants' partner API keys
+type APIKeyService struct {
+	db     *gorm.DB
+	config *config.Config
+	rbac   *RBACService
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(db *gorm.DB, cfg *config.Config) *APIKeyService {
+	return &APIKeyService{
+		db:     db,
+		config: cfg,
+		rbac:   NewRBACService(db, cfg),
+	}
+}
+
+// CreateAPIKeyRequest issues a key for a restaurant's partner system
+type CreateAPIKeyRequest struct {
+	Name      string              `json:"name" binding:"required,max=100"`
+	Scopes    []models.Permission `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time          `json:"expires_at"` // Never expires when omitted
+}
+
+// CreatedAPIKey is a new key with its full value, which is not shown again
+type CreatedAPIKey struct {
+	*models.APIKey
+	Key string `json:"key"`
+}
+
+// GetAPIKeys lists a restaurant's API keys, including revoked ones
+func (s *APIKeyService) GetAPIKeys(restaurantID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := s.db.Where("restaurant_id = ?", restaurantID).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// CreateAPIKey issues a key with the requested scopes. The actor must hold every scope
+// at the restaurant themselves, so a key never has more access than whoever created it.
+func (s *APIKeyService) CreateAPIKey(actorID, restaurantID uint, req *CreateAPIKeyRequest) (*CreatedAPIKey, error) {
+	scopes, err := s.validateScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range scopes {
+		allowed, err := s.rbac.CanAccessRestaurant(actorID, restaurantID, scope)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, fmt.Errorf("you do not have the %s permission at this restaurant", scope)
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expiry must be in the future")
+	}
+
+	// kfd_<prefix>_<secret>: the prefix is stored in the clear to tell keys apart
+	prefix, err := auth.GenerateRandomToken(4)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := auth.GenerateRandomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	prefix = "kfd_" + prefix
+	key := prefix + "_" + secret
+
+	apiKey := &models.APIKey{
+		RestaurantID: restaurantID,
+		Name:         req.Name,
+		Prefix:       prefix,
+		KeyHash:      hashToken(key),
+		Scopes:       scopes,
+		CreatedBy:    actorID,
+		ExpiresAt:    req.ExpiresAt,
+	}
+	if err := s.db.Create(apiKey).Error; err != nil {
+		return nil, err
+	}
+
+	return &CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+// RevokeAPIKey stops a key working immediately
+func (s *APIKeyService) RevokeAPIKey(actorID, restaurantID, keyID uint) error {
+	now := time.Now()
+	result := s.db.Model(&models.APIKey{}).
+		Where("id = ? AND restaurant_id = ? AND revoked_at IS NULL", keyID, restaurantID).
+		Updates(map[string]interface{}{"revoked_at": now, "revoked_by": actorID})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("API key not found")
+	}
+	return nil
+}
+
+// AuthenticateAPIKey returns the active key matching the raw value sent by a client and
+// records its use
+func (s *APIKeyService) AuthenticateAPIKey(rawKey, clientIP string) (*models.APIKey, error) {
+	if !strings.HasPrefix(rawKey, "kfd_") {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var key models.APIKey
+	if err := s.db.Where("key_hash = ?", hashToken(rawKey)).First(&key).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(now)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Partners may poll often, so only write the last-used time once a minute
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyUsageInterval {
+		s.db.Model(&key).Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": clientIP})
+		key.LastUsedAt = &now
+		key.LastUsedIP = clientIP
+	}
+	return &key, nil
+}
+
+// validateScopes rejects permissions that API keys cannot hold and drops repeats
+func (s *APIKeyService) validateScopes(scopes []models.Permission) ([]models.Permission, error) {
+	allowed := make(map[models.Permission]bool, len(models.APIKeyScopes))
+	for _, scope := range models.APIKeyScopes {
+		allowed[scope] = true
+	}
+
+	seen := make(map[models.Permission]bool, len(scopes))
+	unique := make([]models.Permission, 0, len(scopes))
+	for _, scope := range scopes {
+		if !allowed[scope] {
+			return nil, fmt.Errorf("scope %q is not available for API keys", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	return unique, nil
+}
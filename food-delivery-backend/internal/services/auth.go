@@ -2,11 +2,15 @@ package services
 
 import (
 	"errors"
+	"log"
+	"strings"
 	"time"
 
 	"kenyan-food-delivery/internal/auth"
 	"kenyan-food-delivery/internal/config"
 	"kenyan-food-delivery/internal/models"
+	"kenyan-food-delivery/internal/templates"
+	"kenyan-food-delivery/pkg/location"
 
 	"gorm.io/gorm"
 )
@@ -14,16 +18,28 @@ import (
 // AuthService handles authentication operations
 type AuthService struct {
 	db     *gorm.DB
-	config *config.Config
-	email  *EmailService
+	config   *config.Config
+	email    *EmailService
+	sessions *SessionService
+	otp      *OTPService
+	lockout  *LockoutService
+	mfa      *MFAService
+	tokens   *TokenService
+	notifications *NotificationService
 }
 
 // NewAuthService creates a new auth service
 func NewAuthService(db *gorm.DB, cfg *config.Config) *AuthService {
 	return &AuthService{
-		db:     db,
-		config: cfg,
-		email:  NewEmailService(cfg),
+		db:       db,
+		config:   cfg,
+		email:    NewEmailService(db, cfg),
+		sessions: NewSessionService(db, cfg),
+		otp:      NewOTPService(db, cfg, NewSMSService(db, cfg)),
+		lockout:  NewLockoutService(db, cfg),
+		mfa:      NewMFAService(db, cfg),
+		tokens:   NewTokenService(db, cfg),
+		notifications: NewNotificationService(db, cfg),
 	}
 }
 
@@ -31,10 +47,10 @@ func NewAuthService(db *gorm.DB, cfg *config.Config) *AuthService {
 type RegisterRequest struct {
 	Email           string           `json:"email" binding:"required,email"`
 	PhoneNumber     string           `json:"phone_number" binding:"required"`
-	Password        string           `json:"password" binding:"required,min=6"`
+	Password        string           `json:"password" binding:"required"` // Checked against the password policy
 	FirstName       string           `json:"first_name" binding:"required"`
 	LastName        string           `json:"last_name" binding:"required"`
-	Role            models.UserRole  `json:"role"`
+	Role            models.UserRole  `json:"role" binding:"omitempty,oneof=customer restaurant_owner delivery_driver"` // Privileged roles are granted by a super admin
 	PreferredLanguage string         `json:"preferred_language"`
 }
 
@@ -52,7 +68,23 @@ type ForgotPasswordRequest struct {
 // ResetPasswordRequest represents reset password request
 type ResetPasswordRequest struct {
 	Token    string `json:"token" binding:"required"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"` // Checked against the password policy
+}
+
+// PhoneCodeRequest represents a request for a login code by SMS
+type PhoneCodeRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required"`
+}
+
+// PhoneLoginRequest represents a passwordless phone login
+type PhoneLoginRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required"`
+	Code        string `json:"code" binding:"required,numeric,len=6"`
+}
+
+// VerifyPhoneRequest represents phone number verification with an SMS code
+type VerifyPhoneRequest struct {
+	Code string `json:"code" binding:"required,numeric,len=6"`
 }
 
 // VerifyEmailRequest represents email verification request
@@ -68,14 +100,44 @@ type AuthResponse struct {
 	ExpiresIn    int64        `json:"expires_in"`
 }
 
+// MFAChallenge is returned instead of tokens when a login needs a second factor
+type MFAChallenge struct {
+	MFARequired        bool     `json:"mfa_required"`
+	EnrollmentRequired bool     `json:"enrollment_required"` // Policy requires 2FA but no authenticator is enrolled yet
+	ChallengeToken     string   `json:"challenge_token"`
+	ExpiresIn          int64    `json:"expires_in"`
+	Methods            []string `json:"methods"`
+}
+
+// MFAVerifyRequest completes a login with an authenticator or recovery code
+type MFAVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// MFAEnrollRequest starts authenticator enrolment during a login
+type MFAEnrollRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+// MFAEnrolledResponse is the login result of enrolling an authenticator, with the new recovery codes
+type MFAEnrolledResponse struct {
+	*AuthResponse
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
 // Register creates a new user account
-func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
+func (s *AuthService) Register(req *RegisterRequest, device *DeviceInfo) (*AuthResponse, error) {
 	// Check if user already exists
 	var existingUser models.User
 	if err := s.db.Where("email = ? OR phone_number = ?", req.Email, req.PhoneNumber).First(&existingUser).Error; err == nil {
 		return nil, errors.New("user with this email or phone number already exists")
 	}
 
+	if err := s.PasswordPolicy().Validate(req.Password, personalPasswordTerms(req.Email, req.PhoneNumber, req.FirstName, req.LastName)...); err != nil {
+		return nil, err
+	}
+
 	// Hash password
 	hashedPassword, err := auth.HashPassword(req.Password)
 	if err != nil {
@@ -88,17 +150,8 @@ func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
 		role = models.RoleCustomer
 	}
 
-	// Set default language if not provided
-	language := req.PreferredLanguage
-	if language == "" {
-		language = "en"
-	}
-
-	// Generate email verification token
-	verificationToken, err := auth.GenerateEmailVerificationToken()
-	if err != nil {
-		return nil, err
-	}
+	// Fall back to English for missing or unsupported languages
+	language := templates.NormalizeLocale(req.PreferredLanguage)
 
 	// Create user
 	user := &models.User{
@@ -110,119 +163,300 @@ func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
 		Role:              role,
 		Status:            models.StatusPending, // Set to pending until email is verified
 		PreferredLanguage: language,
-		EmailVerificationToken: verificationToken,
 	}
 
-	if err := s.db.Create(user).Error; err != nil {
+	var verificationToken string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		verificationToken, err = s.tokens.Issue(tx, user.ID, models.TokenPurposeEmailVerification, device.IPAddress)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	// Send verification email
-	if err := s.email.SendEmailVerification(user.Email, user.FirstName, verificationToken); err != nil {
-		// Log error but don't fail registration
-		// In production, you might want to use a proper logger
-		// For now, we'll continue without failing
+	if err := s.email.SendEmailVerification(user, verificationToken); err != nil {
+		// Don't fail registration; the user can request another verification email
+		log.Printf("Failed to queue verification email for user %d: %v", user.ID, err)
 	}
 
-	// Generate tokens
-	accessToken, err := auth.GenerateToken(user)
-	if err != nil {
-		return nil, err
-	}
+	return s.startSession(user, device)
+}
 
-	refreshToken, err := auth.GenerateRefreshToken(user)
-	if err != nil {
-		return nil, err
+// Login checks a user's password. It issues tokens straight away, or a challenge when a
+// second factor is enabled or required.
+func (s *AuthService) Login(req *LoginRequest, device *DeviceInfo) (*AuthResponse, *MFAChallenge, error) {
+	accountKey := accountLockoutKey(req.Email)
+	if err := s.lockout.Check(accountKey, ipLockoutKey(device.IPAddress)); err != nil {
+		return nil, nil, err
 	}
 
-	// Remove password from response
-	user.Password = ""
-
-	return &AuthResponse{
-		User:         user,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    24 * 60 * 60, // 24 hours in seconds
-	}, nil
-}
-
-// Login authenticates a user
-func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
 	// Find user by email
 	var user models.User
 	if err := s.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, errors.New("invalid email or password")
+			// Count unknown emails too so guessing is slowed the same way
+			s.recordLoginFailure(nil, accountKey, device.IPAddress)
+			return nil, nil, errors.New("invalid email or password")
 		}
-		return nil, err
+		return nil, nil, err
+	}
+
+	// Check password before revealing anything about the account
+	if err := auth.CheckPassword(req.Password, user.Password); err != nil {
+		s.recordLoginFailure(&user, accountKey, device.IPAddress)
+		return nil, nil, errors.New("invalid email or password")
 	}
 
+	s.lockout.Reset(accountKey)
+
 	// Check if user is active
 	if user.Status != models.StatusActive {
 		if user.Status == models.StatusPending {
-			return nil, errors.New("please verify your email address before logging in")
+			return nil, nil, errors.New("please verify your email address before logging in")
 		}
-		return nil, errors.New("account is not active")
+		return nil, nil, errors.New("account is not active")
 	}
 
-	// Check password
-	if err := auth.CheckPassword(req.Password, user.Password); err != nil {
-		return nil, errors.New("invalid email or password")
+	return s.completeLogin(&user, device)
+}
+
+// recordLoginFailure counts a failed login against the account and client IP,
+// notifying the user when their account becomes locked
+func (s *AuthService) recordLoginFailure(user *models.User, accountKey, ip string) {
+	locked, err := s.lockout.RecordFailure(accountKey, accountLockoutPolicy)
+	if err != nil {
+		log.Printf("Failed to record login failure for %s: %v", accountKey, err)
+	}
+	if _, err := s.lockout.RecordFailure(ipLockoutKey(ip), ipLockoutPolicy); err != nil {
+		log.Printf("Failed to record login failure for %s: %v", ip, err)
+	}
+
+	if locked && user != nil {
+		lockedUntil := time.Now().Add(accountLockoutPolicy.LockDuration)
+		email, err := s.email.AccountLockedEmail(user, lockedUntil)
+		if err != nil {
+			log.Printf("Failed to render account locked email for user %d: %v", user.ID, err)
+		}
+		if err := s.notifications.Dispatch(user.ID, &NotificationMessage{
+			Type:         models.NotificationTypeSecurity,
+			Template:     "account_locked",
+			TemplateData: map[string]interface{}{"LockedUntil": lockedUntil},
+			Email:        email,
+		}); err != nil {
+			log.Printf("Failed to notify user %d of account lock: %v", user.ID, err)
+		}
+	}
+}
+
+// completeLogin either starts a session or, when the user needs a second factor, issues a challenge
+func (s *AuthService) completeLogin(user *models.User, device *DeviceInfo) (*AuthResponse, *MFAChallenge, error) {
+	purpose := ""
+	if user.TwoFactorEnabled {
+		purpose = auth.MFAChallengeVerify
+	} else {
+		required, err := s.mfa.Required(user)
+		if err != nil {
+			return nil, nil, err
+		}
+		if required {
+			purpose = auth.MFAChallengeEnroll
+		}
+	}
+
+	if purpose == "" {
+		response, err := s.finishLogin(user, device)
+		return response, nil, err
+	}
+
+	token, err := auth.GenerateMFAChallengeToken(user, purpose)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	challenge := &MFAChallenge{
+		MFARequired:        true,
+		EnrollmentRequired: purpose == auth.MFAChallengeEnroll,
+		ChallengeToken:     token,
+		ExpiresIn:          int64(auth.MFAChallengeLifetime.Seconds()),
+		Methods:            []string{"totp"},
 	}
+	if user.TwoFactorEnabled {
+		challenge.Methods = append(challenge.Methods, "recovery_code")
+	}
+	return nil, challenge, nil
+}
 
-	// Update last login time
+// finishLogin records the login time and starts a session
+func (s *AuthService) finishLogin(user *models.User, device *DeviceInfo) (*AuthResponse, error) {
 	now := time.Now()
 	user.LastLoginAt = &now
-	s.db.Save(&user)
+	if err := s.db.Model(user).Update("last_login_at", now).Error; err != nil {
+		return nil, err
+	}
 
-	// Generate tokens
-	accessToken, err := auth.GenerateToken(&user)
+	return s.startSession(user, device)
+}
+
+// VerifyMFA completes a login with an authenticator or recovery code. Wrong codes count
+// towards the same lockout as wrong passwords.
+func (s *AuthService) VerifyMFA(req *MFAVerifyRequest, device *DeviceInfo) (*AuthResponse, error) {
+	user, accountKey, err := s.challengeUser(req.ChallengeToken, auth.MFAChallengeVerify, device)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := auth.GenerateRefreshToken(&user)
+	if err := s.mfa.Verify(user.ID, req.Code); err != nil {
+		s.recordLoginFailure(user, accountKey, device.IPAddress)
+		return nil, err
+	}
+
+	s.lockout.Reset(accountKey)
+	return s.finishLogin(user, device)
+}
+
+// StartMFAEnrollment creates an authenticator secret for a user whose login requires 2FA
+func (s *AuthService) StartMFAEnrollment(req *MFAEnrollRequest, device *DeviceInfo) (*MFASetup, error) {
+	user, _, err := s.challengeUser(req.ChallengeToken, auth.MFAChallengeEnroll, device)
 	if err != nil {
 		return nil, err
 	}
 
-	// Remove password from response
-	user.Password = ""
+	return s.mfa.Setup(user.ID)
+}
 
-	return &AuthResponse{
-		User:         &user,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    24 * 60 * 60, // 24 hours in seconds
-	}, nil
+// ConfirmMFAEnrollment enables the new authenticator with a code from it and completes the login
+func (s *AuthService) ConfirmMFAEnrollment(req *MFAVerifyRequest, device *DeviceInfo) (*MFAEnrolledResponse, error) {
+	user, accountKey, err := s.challengeUser(req.ChallengeToken, auth.MFAChallengeEnroll, device)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := s.mfa.Enable(user.ID, req.Code)
+	if err != nil {
+		if err == errInvalidMFACode {
+			s.recordLoginFailure(user, accountKey, device.IPAddress)
+		}
+		return nil, err
+	}
+
+	s.lockout.Reset(accountKey)
+	response, err := s.finishLogin(user, device)
+	if err != nil {
+		return nil, err
+	}
+	return &MFAEnrolledResponse{AuthResponse: response, RecoveryCodes: codes}, nil
+}
+
+// DisableMFA turns off two-factor authentication for a signed-in user. Wrong passwords and
+// codes count towards the same lockout as a login.
+func (s *AuthService) DisableMFA(userID uint, req *DisableMFARequest, ip string) error {
+	user, accountKey, err := s.lockoutUser(userID, ip)
+	if err != nil {
+		return err
+	}
+
+	if err := s.mfa.Disable(user.ID, req); err != nil {
+		if err == errInvalidMFACode || err == errInvalidMFAPassword {
+			s.recordLoginFailure(user, accountKey, ip)
+		}
+		return err
+	}
+
+	s.lockout.Reset(accountKey)
+	return nil
 }
 
-// RefreshToken generates new access token using refresh token
-func (s *AuthService) RefreshToken(refreshToken string) (*AuthResponse, error) {
-	// Validate refresh token
-	claims, err := auth.ValidateRefreshToken(refreshToken)
+// RegenerateRecoveryCodes replaces a signed-in user's recovery codes. Wrong codes count
+// towards the same lockout as a login.
+func (s *AuthService) RegenerateRecoveryCodes(userID uint, code, ip string) ([]string, error) {
+	user, accountKey, err := s.lockoutUser(userID, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := s.mfa.RegenerateRecoveryCodes(user.ID, code)
 	if err != nil {
-		return nil, errors.New("invalid refresh token")
+		if err == errInvalidMFACode {
+			s.recordLoginFailure(user, accountKey, ip)
+		}
+		return nil, err
 	}
 
-	// Get user
+	s.lockout.Reset(accountKey)
+	return codes, nil
+}
+
+// lockoutUser loads a signed-in user and refuses if their account or IP address is locked out
+func (s *AuthService) lockoutUser(userID uint, ip string) (*models.User, string, error) {
 	var user models.User
-	if err := s.db.First(&user, claims.UserID).Error; err != nil {
-		return nil, errors.New("user not found")
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, "", errors.New("user not found")
+		}
+		return nil, "", err
 	}
 
-	// Check if user is still active
+	accountKey := accountLockoutKey(user.Email)
+	if err := s.lockout.Check(accountKey, ipLockoutKey(ip)); err != nil {
+		return nil, "", err
+	}
+	return &user, accountKey, nil
+}
+
+// challengeUser loads the user an MFA challenge token was issued to, checking lockouts and
+// that the account is still active
+func (s *AuthService) challengeUser(token, purpose string, device *DeviceInfo) (*models.User, string, error) {
+	claims, err := auth.ValidateMFAChallengeToken(token, purpose)
+	if err != nil {
+		return nil, "", errors.New("login challenge is invalid or has expired, please sign in again")
+	}
+
+	accountKey := accountLockoutKey(claims.Email)
+	if err := s.lockout.Check(accountKey, ipLockoutKey(device.IPAddress)); err != nil {
+		return nil, "", err
+	}
+
+	var user models.User
+	if err := s.db.First(&user, claims.UserID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, "", errors.New("login challenge is invalid or has expired, please sign in again")
+		}
+		return nil, "", err
+	}
 	if user.Status != models.StatusActive {
-		return nil, errors.New("account is not active")
+		return nil, "", errors.New("account is not active")
 	}
 
-	// Generate new tokens
-	accessToken, err := auth.GenerateToken(&user)
+	return &user, accountKey, nil
+}
+
+// RefreshToken rotates a refresh token and issues a new access token for the same session
+func (s *AuthService) RefreshToken(refreshToken string, device *DeviceInfo) (*AuthResponse, error) {
+	user, issued, err := s.sessions.Rotate(refreshToken, device)
 	if err != nil {
 		return nil, err
 	}
 
-	newRefreshToken, err := auth.GenerateRefreshToken(&user)
+	return s.buildAuthResponse(user, issued)
+}
+
+// startSession creates a new session for a user and issues its tokens
+func (s *AuthService) startSession(user *models.User, device *DeviceInfo) (*AuthResponse, error) {
+	issued, err := s.sessions.CreateSession(user, device)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildAuthResponse(user, issued)
+}
+
+// buildAuthResponse issues an access token bound to a session
+func (s *AuthService) buildAuthResponse(user *models.User, issued *IssuedSession) (*AuthResponse, error) {
+	accessToken, err := auth.GenerateToken(user, issued.Session.FamilyID)
 	if err != nil {
 		return nil, err
 	}
@@ -231,13 +465,46 @@ func (s *AuthService) RefreshToken(refreshToken string) (*AuthResponse, error) {
 	user.Password = ""
 
 	return &AuthResponse{
-		User:         &user,
+		User:         user,
 		AccessToken:  accessToken,
-		RefreshToken: newRefreshToken,
+		RefreshToken: issued.RefreshToken,
 		ExpiresIn:    24 * 60 * 60, // 24 hours in seconds
 	}, nil
 }
 
+// PasswordPolicy returns the configured rules for new passwords
+func (s *AuthService) PasswordPolicy() auth.PasswordPolicy {
+	return auth.PasswordPolicy{
+		MinLength:     s.config.PasswordMinLength,
+		MaxLength:     auth.MaxPasswordLength,
+		RequireUpper:  s.config.PasswordRequireUpper,
+		RequireLower:  s.config.PasswordRequireLower,
+		RequireDigit:  s.config.PasswordRequireDigit,
+		RequireSymbol: s.config.PasswordRequireSymbol,
+		RejectCommon:  s.config.PasswordRejectCommon,
+	}
+}
+
+// personalPasswordTerms lists the details about a user that their password must not contain.
+// Phone numbers are reduced to the subscriber digits so every written format is caught.
+func personalPasswordTerms(email, phoneNumber, firstName, lastName string) []string {
+	terms := []string{email, firstName, lastName}
+	if at := strings.Index(email, "@"); at > 0 {
+		terms = append(terms, email[:at])
+	}
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phoneNumber)
+	if len(digits) > 9 {
+		digits = digits[len(digits)-9:]
+	}
+	return append(terms, digits)
+}
+
 // GetUserByID gets user by ID
 func (s *AuthService) GetUserByID(userID uint) (*models.User, error) {
 	var user models.User
@@ -250,38 +517,48 @@ func (s *AuthService) GetUserByID(userID uint) (*models.User, error) {
 	return &user, nil
 }
 
-// VerifyEmail verifies user email with token
+// VerifyEmail verifies user email with a single-use token
 func (s *AuthService) VerifyEmail(req *VerifyEmailRequest) error {
 	var user models.User
-	if err := s.db.Where("email_verification_token = ?", req.Token).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return errors.New("invalid verification token")
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		token, err := s.tokens.Consume(tx, req.Token, models.TokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+		if err := tx.First(&user, token.UserID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errTokenInvalid
+			}
+			return err
 		}
-		return err
-	}
-
-	// Update user status and clear verification token
-	now := time.Now()
-	user.Status = models.StatusActive
-	user.IsVerified = true
-	user.EmailVerifiedAt = &now
-	user.EmailVerificationToken = "" // Clear the token
 
-	if err := s.db.Save(&user).Error; err != nil {
+		// Activate only accounts waiting on verification; suspended ones stay suspended
+		now := time.Now()
+		updates := map[string]interface{}{"is_verified": true, "email_verified_at": now}
+		if user.Status == models.StatusPending {
+			updates["status"] = models.StatusActive
+		}
+		return tx.Model(&user).Updates(updates).Error
+	})
+	if err != nil {
 		return err
 	}
 
 	// Send welcome email
-	if err := s.email.SendWelcomeEmail(user.Email, user.FirstName); err != nil {
-		// Log error but don't fail verification
-		// In production, you might want to use a proper logger
+	if err := s.email.SendWelcomeEmail(&user); err != nil {
+		log.Printf("Failed to queue welcome email for user %d: %v", user.ID, err)
 	}
 
 	return nil
 }
 
 // ForgotPassword initiates password reset process
-func (s *AuthService) ForgotPassword(req *ForgotPasswordRequest) error {
+func (s *AuthService) ForgotPassword(req *ForgotPasswordRequest, clientIP string) error {
+	if err := s.lockout.Throttle(requestThrottlePolicy,
+		throttleKey("forgot_password", req.Email), throttleKey("forgot_password_ip", clientIP)); err != nil {
+		return err
+	}
+
 	var user models.User
 	if err := s.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -291,91 +568,229 @@ func (s *AuthService) ForgotPassword(req *ForgotPasswordRequest) error {
 		return err
 	}
 
-	// Generate password reset token
-	resetToken, err := auth.GeneratePasswordResetToken()
+	// Generate password reset token (valid for 1 hour)
+	resetToken, err := s.tokens.Issue(s.db, user.ID, models.TokenPurposePasswordReset, clientIP)
 	if err != nil {
 		return err
 	}
 
-	// Set token and expiration (1 hour)
-	expiration := time.Now().Add(time.Hour)
-	user.PasswordResetToken = resetToken
-	user.PasswordResetExpires = &expiration
+	// Send password reset email
+	if err := s.email.SendPasswordReset(&user, resetToken); err != nil {
+		// Don't reveal to the caller whether the email could be sent
+		log.Printf("Failed to queue password reset email for user %d: %v", user.ID, err)
+	}
+
+	return nil
+}
 
-	if err := s.db.Save(&user).Error; err != nil {
+// ResetPassword resets user password using token
+func (s *AuthService) ResetPassword(req *ResetPasswordRequest, clientIP string) error {
+	ipKey := ipLockoutKey(clientIP)
+	if err := s.lockout.Check(ipKey); err != nil {
 		return err
 	}
 
-	// Send password reset email
-	if err := s.email.SendPasswordReset(user.Email, user.FirstName, resetToken); err != nil {
-		// Log error but don't fail the process
-		// In production, you might want to use a proper logger
+	// The token is only spent if the whole reset succeeds, so a rejected password can be retried
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		token, err := s.tokens.Consume(tx, req.Token, models.TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+		if err := tx.First(&user, token.UserID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errTokenInvalid
+			}
+			return err
+		}
+
+		if err := s.PasswordPolicy().Validate(req.Password, personalPasswordTerms(user.Email, user.PhoneNumber, user.FirstName, user.LastName)...); err != nil {
+			return err
+		}
+
+		hashedPassword, err := auth.HashPassword(req.Password)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+
+		// Whoever knew the old password should not stay logged in
+		_, err = revokeUserSessions(tx, user.ID, models.SessionRevokedPasswordReset)
+		return err
+	})
+	if err != nil {
+		if err == errTokenInvalid {
+			s.lockout.RecordFailure(ipKey, ipLockoutPolicy)
+		}
+		return err
 	}
 
-	return nil
+	// Proving ownership of the email unlocks the account
+	return s.lockout.Reset(accountLockoutKey(user.Email))
 }
 
-// ResetPassword resets user password using token
-func (s *AuthService) ResetPassword(req *ResetPasswordRequest) error {
+// ResendVerificationEmail resends verification email
+func (s *AuthService) ResendVerificationEmail(email, clientIP string) error {
+	if err := s.lockout.Throttle(requestThrottlePolicy,
+		throttleKey("resend_verification", email), throttleKey("resend_verification_ip", clientIP)); err != nil {
+		return err
+	}
+
 	var user models.User
-	if err := s.db.Where("password_reset_token = ?", req.Token).First(&user).Error; err != nil {
+	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return errors.New("invalid reset token")
+			return errors.New("user not found")
 		}
 		return err
 	}
 
-	// Check if token has expired
-	if user.PasswordResetExpires == nil || time.Now().After(*user.PasswordResetExpires) {
-		return errors.New("reset token has expired")
+	// Check if already verified
+	if user.IsVerified {
+		return errors.New("email is already verified")
 	}
 
-	// Hash new password
-	hashedPassword, err := auth.HashPassword(req.Password)
+	// Earlier links keep working until they expire or one of them is used
+	verificationToken, err := s.tokens.Issue(s.db, user.ID, models.TokenPurposeEmailVerification, clientIP)
 	if err != nil {
 		return err
 	}
 
-	// Update password and clear reset token
-	user.Password = hashedPassword
-	user.PasswordResetToken = ""
-	user.PasswordResetExpires = nil
+	// Send verification email
+	return s.email.SendEmailVerification(&user, verificationToken)
+}
 
-	if err := s.db.Save(&user).Error; err != nil {
+// RequestPhoneLoginCode sends a login code to a registered phone number
+func (s *AuthService) RequestPhoneLoginCode(req *PhoneCodeRequest, clientIP string) error {
+	// Per-number limits are enforced by the OTP service; this stops one client spraying many numbers
+	if err := s.lockout.Throttle(requestThrottlePolicy, throttleKey("phone_code_ip", clientIP)); err != nil {
 		return err
 	}
 
-	return nil
+	user, err := s.findUserByPhone(req.PhoneNumber)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		// Don't reveal whether the number is registered
+		return nil
+	}
+
+	return s.otp.SendCode(req.PhoneNumber, models.OTPPurposeLogin, &user.ID)
 }
 
-// ResendVerificationEmail resends verification email
-func (s *AuthService) ResendVerificationEmail(email string) error {
+// LoginWithPhone authenticates a user with their phone number and an SMS code, subject to
+// the same second-factor rules as Login
+func (s *AuthService) LoginWithPhone(req *PhoneLoginRequest, device *DeviceInfo) (*AuthResponse, *MFAChallenge, error) {
+	// Failures are counted against the number as well as the account, so guesses at a
+	// number are slowed whether or not it belongs to anyone
+	phoneKey := throttleKey("phone", req.PhoneNumber)
+	if err := s.lockout.Check(phoneKey, ipLockoutKey(device.IPAddress)); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.findUserByPhone(req.PhoneNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		s.recordLoginFailure(nil, phoneKey, device.IPAddress)
+		return nil, nil, errors.New("code is invalid or has expired")
+	}
+
+	accountKey := accountLockoutKey(user.Email)
+	if err := s.lockout.Check(accountKey); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.otp.VerifyCode(req.PhoneNumber, models.OTPPurposeLogin, req.Code); err != nil {
+		if _, recordErr := s.lockout.RecordFailure(phoneKey, accountLockoutPolicy); recordErr != nil {
+			log.Printf("Failed to record login failure for %s: %v", phoneKey, recordErr)
+		}
+		s.recordLoginFailure(user, accountKey, device.IPAddress)
+		return nil, nil, err
+	}
+
+	s.lockout.Reset(accountKey, phoneKey)
+
+	if user.Status != models.StatusActive {
+		if user.Status == models.StatusPending {
+			return nil, nil, errors.New("please verify your email address before logging in")
+		}
+		return nil, nil, errors.New("account is not active")
+	}
+
+	// Receiving the code proves the user holds the number
+	if user.PhoneVerifiedAt == nil {
+		now := time.Now()
+		user.PhoneVerifiedAt = &now
+		if err := s.db.Model(user).Update("phone_verified_at", now).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return s.completeLogin(user, device)
+}
+
+// SendPhoneVerificationCode sends a verification code to the user's phone number
+func (s *AuthService) SendPhoneVerificationCode(userID uint) error {
 	var user models.User
-	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := s.db.First(&user, userID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return errors.New("user not found")
 		}
 		return err
 	}
 
-	// Check if already verified
-	if user.IsVerified {
-		return errors.New("email is already verified")
+	if user.PhoneVerifiedAt != nil {
+		return errors.New("phone number is already verified")
 	}
 
-	// Generate new verification token if needed
-	if user.EmailVerificationToken == "" {
-		verificationToken, err := auth.GenerateEmailVerificationToken()
-		if err != nil {
-			return err
-		}
-		user.EmailVerificationToken = verificationToken
-		if err := s.db.Save(&user).Error; err != nil {
-			return err
+	return s.otp.SendCode(user.PhoneNumber, models.OTPPurposePhoneVerification, &user.ID)
+}
+
+// VerifyPhone marks the user's phone number as verified using an SMS code
+func (s *AuthService) VerifyPhone(userID uint, req *VerifyPhoneRequest) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("user not found")
 		}
+		return nil, err
 	}
 
-	// Send verification email
-	return s.email.SendEmailVerification(user.Email, user.FirstName, user.EmailVerificationToken)
+	if user.PhoneVerifiedAt != nil {
+		return nil, errors.New("phone number is already verified")
+	}
+
+	if err := s.otp.VerifyCode(user.PhoneNumber, models.OTPPurposePhoneVerification, req.Code); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.PhoneVerifiedAt = &now
+	if err := s.db.Model(&user).Update("phone_verified_at", now).Error; err != nil {
+		return nil, err
+	}
+
+	user.Password = ""
+	return &user, nil
 }
 
+// findUserByPhone looks a user up by any common format of their phone number; nil means no match
+func (s *AuthService) findUserByPhone(phoneNumber string) (*models.User, error) {
+	normalized, err := location.NormalizePhoneNumber(phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.db.Where("phone_number IN ?", location.PhoneNumberVariants(normalized)).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"log"
+	"time"
+)
+
+// runPeriodically calls a cleanup task every interval, logging how many rows it removed.
+// It never returns, so callers start it in its own goroutine.
+func runPeriodically(interval time.Duration, name string, task func() (int64, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		deleted, err := task()
+		if err != nil {
+			log.Printf("Failed to clean up %s: %v", name, err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("Deleted %d %s", deleted, name)
+		}
+	}
+}
@@ -1,171 +1,183 @@
 package services
 
 import (
+	"bytes"
 	"fmt"
-	"net/smtp"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"sort"
+	"time"
 
 	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+	"kenyan-food-delivery/internal/templates"
+
+	"gorm.io/gorm"
 )
 
-// EmailService handles email operations
+// EmailService renders emails and queues them in the outbox
 type EmailService struct {
 	config *config.Config
+	outbox *OutboxService
 }
 
 // NewEmailService creates a new email service
-func NewEmailService(cfg *config.Config) *EmailService {
+func NewEmailService(db *gorm.DB, cfg *config.Config) *EmailService {
 	return &EmailService{
 		config: cfg,
+		outbox: NewOutboxService(db, cfg),
 	}
 }
 
-// SendEmail sends an email
-func (s *EmailService) SendEmail(to, subject, body string) error {
-	if s.config.SMTPUsername == "" || s.config.SMTPPassword == "" {
-		return fmt.Errorf("email configuration is missing")
-	}
+// Send queues a rendered email, with extra headers such as List-Unsubscribe. It returns
+// once the email is stored; the outbox worker delivers it and retries failures.
+func (s *EmailService) Send(to string, email *templates.Email, headers map[string]string) error {
+	return s.outbox.EnqueueEmail(to, email, headers, false)
+}
 
-	from := s.config.EmailFrom
+// deliverEmail formats an email as multipart/alternative and hands it to the transport
+func deliverEmail(cfg *config.Config, transport EmailTransport, to string, email *templates.Email, headers map[string]string) error {
+	from := cfg.EmailFrom
 	if from == "" {
-		from = s.config.SMTPUsername
+		from = cfg.SMTPUsername
+	}
+	if from == "" {
+		from = "noreply@localhost" // Only useful with the capture transport; real servers reject it
 	}
 
-	auth := smtp.PlainAuth("", s.config.SMTPUsername, s.config.SMTPPassword, s.config.SMTPHost)
-
-	msg := []byte(fmt.Sprintf("To: %s\r\n"+
-		"From: %s\r\n"+
-		"Subject: %s\r\n"+
-		"Content-Type: text/html; charset=\"UTF-8\"\r\n"+
-		"\r\n"+
-		"%s\r\n", to, from, subject, body))
-
-	addr := fmt.Sprintf("%s:%d", s.config.SMTPHost, s.config.SMTPPort)
-	return smtp.SendMail(addr, auth, from, []string{to}, msg)
+	msg, err := buildMessage(from, to, email, headers)
+	if err != nil {
+		return err
+	}
+	return transport.Send(from, []string{to}, msg)
 }
 
 // SendEmailVerification sends email verification email
-func (s *EmailService) SendEmailVerification(email, firstName, token string) error {
-	subject := "Verify Your Email - Kenyan Food Delivery"
-	
+func (s *EmailService) SendEmailVerification(user *models.User, token string) error {
 	// Create verification URL using backend URL
 	verifyURL := fmt.Sprintf("%s/api/v1/auth/verify-email?token=%s", s.config.BackendURL, token)
-	
-	body := fmt.Sprintf(`
-		<html>
-		<body style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto; padding: 20px;">
-			<div style="background-color: #f8f9fa; padding: 20px; border-radius: 8px;">
-				<h2 style="color: #333; text-align: center;">Welcome to Kenyan Food Delivery!</h2>
-				<p>Hi %s,</p>
-				<p>Thank you for creating an account with Kenyan Food Delivery. Please click the button below to verify your email address:</p>
-				
-				<div style="text-align: center; margin: 30px 0;">
-					<a href="%s" style="background-color: #007bff; color: white; padding: 12px 24px; text-decoration: none; border-radius: 5px; display: inline-block;">
-						Verify Email Address
-					</a>
-				</div>
-				
-				<p>If the button doesn't work, you can copy and paste this link into your browser:</p>
-				<p style="background-color: #f1f1f1; padding: 10px; border-radius: 4px; word-break: break-all;">%s</p>
-				
-				<p>This verification link will expire in 24 hours.</p>
-				
-				<p>If you didn't create this account, please ignore this email.</p>
-				
-				<hr style="border: 1px solid #eee; margin: 30px 0;">
-				<p style="font-size: 12px; color: #666; text-align: center;">
-					Kenyan Food Delivery<br>
-					Nairobi, Kenya
-				</p>
-			</div>
-		</body>
-		</html>
-	`, firstName, verifyURL, verifyURL)
-
-	return s.SendEmail(email, subject, body)
+
+	return s.sendTemplate(user.Email, user.PreferredLanguage, "verify_email", map[string]interface{}{
+		"FirstName": user.FirstName,
+		"VerifyURL": verifyURL,
+	})
 }
 
 // SendPasswordReset sends password reset email
-func (s *EmailService) SendPasswordReset(email, firstName, token string) error {
-	subject := "Reset Your Password - Kenyan Food Delivery"
-	
+func (s *EmailService) SendPasswordReset(user *models.User, token string) error {
 	// Create reset URL using backend URL
 	resetURL := fmt.Sprintf("%s/api/v1/auth/reset-password?token=%s", s.config.BackendURL, token)
-	
-	body := fmt.Sprintf(`
-		<html>
-		<body style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto; padding: 20px;">
-			<div style="background-color: #f8f9fa; padding: 20px; border-radius: 8px;">
-				<h2 style="color: #333; text-align: center;">Reset Your Password</h2>
-				<p>Hi %s,</p>
-				<p>We received a request to reset your password for your Kenyan Food Delivery account. Click the button below to reset your password:</p>
-				
-				<div style="text-align: center; margin: 30px 0;">
-					<a href="%s" style="background-color: #dc3545; color: white; padding: 12px 24px; text-decoration: none; border-radius: 5px; display: inline-block;">
-						Reset Password
-					</a>
-				</div>
-				
-				<p>If the button doesn't work, you can copy and paste this link into your browser:</p>
-				<p style="background-color: #f1f1f1; padding: 10px; border-radius: 4px; word-break: break-all;">%s</p>
-				
-				<p>This reset link will expire in 1 hour for security reasons.</p>
-				
-				<p>If you didn't request a password reset, please ignore this email. Your password will remain unchanged.</p>
-				
-				<hr style="border: 1px solid #eee; margin: 30px 0;">
-				<p style="font-size: 12px; color: #666; text-align: center;">
-					Kenyan Food Delivery<br>
-					Nairobi, Kenya
-				</p>
-			</div>
-		</body>
-		</html>
-	`, firstName, resetURL, resetURL)
-
-	return s.SendEmail(email, subject, body)
+
+	return s.sendTemplate(user.Email, user.PreferredLanguage, "password_reset", map[string]interface{}{
+		"FirstName": user.FirstName,
+		"ResetURL":  resetURL,
+	})
 }
 
 // SendWelcomeEmail sends welcome email after email verification
-func (s *EmailService) SendWelcomeEmail(email, firstName string) error {
-	subject := "Welcome to Kenyan Food Delivery!"
-	
-	body := fmt.Sprintf(`
-		<html>
-		<body style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto; padding: 20px;">
-			<div style="background-color: #f8f9fa; padding: 20px; border-radius: 8px;">
-				<h2 style="color: #333; text-align: center;">Welcome to Kenyan Food Delivery! 🍽️</h2>
-				<p>Hi %s,</p>
-				<p>Your email has been successfully verified! Welcome to Kenya's premier food delivery platform.</p>
-				
-				<h3 style="color: #333;">What's Next?</h3>
-				<ul>
-					<li>🔍 <strong>Explore Restaurants:</strong> Browse through hundreds of local restaurants</li>
-					<li>🏠 <strong>Add Your Address:</strong> Set up your delivery locations</li>
-					<li>🍕 <strong>Order Your Favorites:</strong> Discover amazing Kenyan and international cuisine</li>
-					<li>📱 <strong>Track Your Order:</strong> Real-time delivery tracking</li>
-				</ul>
-				
-				<h3 style="color: #333;">Featured Cuisines:</h3>
-				<p>🇰🇪 Traditional Kenyan • 🌶️ Swahili • 🇮🇳 Indian • 🇨🇳 Chinese • 🇮🇹 Italian</p>
-				
-				<div style="text-align: center; margin: 30px 0;">
-					<p style="background-color: #28a745; color: white; padding: 12px 24px; border-radius: 5px; display: inline-block;">
-						Download our mobile app to start ordering!
-					</p>
-				</div>
-				
-				<p>Need help? Our customer support team is available 24/7 to assist you.</p>
-				
-				<hr style="border: 1px solid #eee; margin: 30px 0;">
-				<p style="font-size: 12px; color: #666; text-align: center;">
-					Kenyan Food Delivery<br>
-					Nairobi, Kenya<br>
-					<a href="mailto:support@kenyanfooddelivery.com">support@kenyanfooddelivery.com</a>
-				</p>
-			</div>
-		</body>
-		</html>
-	`, firstName)
-
-	return s.SendEmail(email, subject, body)
+func (s *EmailService) SendWelcomeEmail(user *models.User) error {
+	return s.sendTemplate(user.Email, user.PreferredLanguage, "welcome", map[string]interface{}{
+		"FirstName": user.FirstName,
+	})
+}
+
+// AccountLockedEmail builds the email telling a user their account was temporarily locked after failed logins
+func (s *EmailService) AccountLockedEmail(user *models.User, lockedUntil time.Time) (*templates.Email, error) {
+	return templates.RenderEmail(user.PreferredLanguage, "account_locked", map[string]interface{}{
+		"FirstName":   user.FirstName,
+		"LockedUntil": lockedUntil,
+	})
+}
+
+// SendStaffInvitationEmail sends a restaurant staff invitation code. language is the
+// invitee's preferred language when they already have an account.
+func (s *EmailService) SendStaffInvitationEmail(email, language, restaurantName, role, code string, expiresAt time.Time) error {
+	return s.sendTemplate(email, language, "staff_invitation", map[string]interface{}{
+		"RestaurantName": restaurantName,
+		"Role":           role,
+		"Code":           code,
+		"ExpiresAt":      expiresAt,
+	})
+}
+
+// NotificationEmail builds the email for a notification sent through the dispatcher.
+// unsubscribeURL adds an unsubscribe link to the footer when set.
+func (s *EmailService) NotificationEmail(user *models.User, title, message, unsubscribeURL string) (*templates.Email, error) {
+	return templates.RenderEmail(user.PreferredLanguage, "notification", map[string]interface{}{
+		"FirstName":      user.FirstName,
+		"Title":          title,
+		"Message":        message,
+		"UnsubscribeURL": unsubscribeURL,
+	})
+}
+
+// sensitiveEmails are the templates carrying a link or code that expires
+var sensitiveEmails = map[string]bool{
+	"verify_email":     true,
+	"password_reset":   true,
+	"staff_invitation": true,
+}
+
+// sendTemplate renders an email in the recipient's language and queues it
+func (s *EmailService) sendTemplate(to, language, name string, data map[string]interface{}) error {
+	email, err := templates.RenderEmail(language, name, data)
+	if err != nil {
+		return err
+	}
+	return s.outbox.EnqueueEmail(to, email, nil, sensitiveEmails[name])
+}
+
+// buildMessage formats an email with plain-text and HTML alternatives, text first so
+// clients that can show HTML prefer it
+func buildMessage(from, to string, email *templates.Email, headers map[string]string) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	alternatives := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", email.Text},
+		{"text/html; charset=UTF-8", email.HTML},
+	}
+	for _, alternative := range alternatives {
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {alternative.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := quotedprintable.NewWriter(part)
+		if _, err := encoder.Write([]byte(alternative.content)); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", email.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&msg, "%s: %s\r\n", name, headers[name])
+	}
+
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
 }
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"kenyan-food-delivery/internal/config"
+)
+
+// EmailTransport delivers a formatted email message
+type EmailTransport interface {
+	Name() string
+	Send(from string, to []string, msg []byte) error
+}
+
+var (
+	emailTransport     EmailTransport
+	emailTransportOnce sync.Once
+)
+
+// sharedEmailTransport returns the process-wide transport named in the config, so the
+// capture transport sees every message
+func sharedEmailTransport(cfg *config.Config) EmailTransport {
+	emailTransportOnce.Do(func() {
+		if cfg.EmailTransport == "capture" {
+			emailTransport = NewCaptureTransport(cfg.EmailCaptureDir)
+			return
+		}
+		emailTransport = NewSMTPTransport(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPSecurity)
+	})
+	return emailTransport
+}
+
+// SMTP connection security modes
+const (
+	SMTPSecurityStartTLS = "starttls"
+	SMTPSecurityTLS      = "tls" // Implicit TLS, usually on port 465
+	SMTPSecurityNone     = "none"
+)
+
+const smtpTimeout = 30 * time.Second
+
+// SMTPTransport sends email through an SMTP server, authenticating when a username is set
+type SMTPTransport struct {
+	host     string
+	port     int
+	username string
+	password string
+	security string
+}
+
+// NewSMTPTransport creates an SMTP transport; security is starttls, tls or none
+func NewSMTPTransport(host string, port int, username, password, security string) *SMTPTransport {
+	return &SMTPTransport{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		security: security,
+	}
+}
+
+// Name identifies the transport in logs
+func (t *SMTPTransport) Name() string {
+	return "smtp"
+}
+
+// Send delivers the message over a new connection
+func (t *SMTPTransport) Send(from string, to []string, msg []byte) error {
+	if t.host == "" {
+		return errors.New("email configuration is missing")
+	}
+
+	addr := net.JoinHostPort(t.host, fmt.Sprint(t.port))
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	tlsConfig := &tls.Config{ServerName: t.host}
+
+	var conn net.Conn
+	var err error
+	if t.security == SMTPSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(conn, t.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if t.security == SMTPSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if t.username != "" {
+		// PlainAuth refuses to send the password over an unencrypted connection to a remote host
+		if err := client.Auth(smtp.PlainAuth("", t.username, t.password, t.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(msg); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// captureLimit is how many messages the capture transport keeps in memory
+const captureLimit = 200
+
+// CapturedEmail is an email kept by CaptureTransport instead of being sent
+type CapturedEmail struct {
+	ID         uint      `json:"id"`
+	From       string    `json:"from"`
+	To         []string  `json:"to"`
+	Subject    string    `json:"subject"`
+	File       string    `json:"file,omitempty"` // Path of the .eml file, when writing to disk
+	Size       int       `json:"size"`
+	CapturedAt time.Time `json:"captured_at"`
+	raw        []byte
+}
+
+// Raw returns the complete message, as it would have been sent
+func (e *CapturedEmail) Raw() []byte {
+	return e.raw
+}
+
+// CaptureTransport keeps emails in memory and writes each to an .eml file instead of
+// sending it, so flows such as sign-up can be tested offline
+type CaptureTransport struct {
+	mu       sync.Mutex
+	dir      string
+	messages []*CapturedEmail
+	nextID   uint
+}
+
+// NewCaptureTransport creates a capture transport; dir is where .eml files are written,
+// or empty to keep messages in memory only
+func NewCaptureTransport(dir string) *CaptureTransport {
+	return &CaptureTransport{dir: dir, nextID: 1}
+}
+
+// Name identifies the transport in logs
+func (t *CaptureTransport) Name() string {
+	return "capture"
+}
+
+// Send captures the message
+func (t *CaptureTransport) Send(from string, to []string, msg []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	captured := &CapturedEmail{
+		ID:         t.nextID,
+		From:       from,
+		To:         append([]string(nil), to...),
+		Subject:    messageSubject(msg),
+		Size:       len(msg),
+		CapturedAt: time.Now(),
+		raw:        append([]byte(nil), msg...),
+	}
+
+	if t.dir != "" {
+		if err := os.MkdirAll(t.dir, 0o755); err != nil {
+			return err
+		}
+		name := fmt.Sprintf("%s-%06d.eml", captured.CapturedAt.Format("20060102-150405"), captured.ID)
+		captured.File = filepath.Join(t.dir, name)
+		if err := os.WriteFile(captured.File, msg, 0o644); err != nil {
+			return err
+		}
+	}
+
+	t.nextID++
+	t.messages = append(t.messages, captured)
+	if len(t.messages) > captureLimit {
+		t.messages = t.messages[len(t.messages)-captureLimit:]
+	}
+	log.Printf("Captured email %d to %v: %s", captured.ID, to, captured.Subject)
+	return nil
+}
+
+// Messages returns the captured messages, newest first
+func (t *CaptureTransport) Messages() []*CapturedEmail {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	messages := make([]*CapturedEmail, len(t.messages))
+	for i, message := range t.messages {
+		messages[len(t.messages)-1-i] = message
+	}
+	return messages
+}
+
+// Message returns a captured message by ID
+func (t *CaptureTransport) Message(id uint) (*CapturedEmail, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, message := range t.messages {
+		if message.ID == id {
+			return message, true
+		}
+	}
+	return nil, false
+}
+
+// Reset forgets the captured messages; .eml files are left on disk
+func (t *CaptureTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = nil
+}
+
+// messageSubject reads the decoded Subject header of a formatted message
+func messageSubject(msg []byte) string {
+	parsed, err := mail.ReadMessage(bytes.NewReader(msg))
+	if err != nil {
+		return ""
+	}
+	subject := parsed.Header.Get("Subject")
+	decoded, err := new(mime.WordDecoder).DecodeHeader(subject)
+	if err != nil {
+		return subject
+	}
+	return decoded
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LockoutPolicy controls progressive delays and lockout for one kind of key
+type LockoutPolicy struct {
+	DelayAfter   int           // Failures allowed before delays start
+	MaxDelay     time.Duration // Cap on the progressive delay
+	LockAfter    int           // Failures that trigger a full lockout
+	LockDuration time.Duration
+	ResetAfter   time.Duration // Quiet period after which failures are forgotten
+}
+
+var (
+	// accountLockoutPolicy protects a single account from password or code guessing
+	accountLockoutPolicy = LockoutPolicy{DelayAfter: 3, MaxDelay: time.Minute, LockAfter: 10, LockDuration: 15 * time.Minute, ResetAfter: time.Hour}
+	// ipLockoutPolicy slows a single client trying many accounts
+	ipLockoutPolicy = LockoutPolicy{DelayAfter: 10, MaxDelay: time.Minute, LockAfter: 50, LockDuration: 15 * time.Minute, ResetAfter: time.Hour}
+	// requestThrottlePolicy limits endpoints that send email or SMS; every request counts
+	requestThrottlePolicy = LockoutPolicy{DelayAfter: 3, MaxDelay: 5 * time.Minute, LockAfter: 10, LockDuration: time.Hour, ResetAfter: time.Hour}
+)
+
+// LockoutError is returned while a key is delayed or locked
+type LockoutError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+// Error describes how long the caller has to wait
+func (e *LockoutError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("too many failed attempts, temporarily locked; try again in %d minutes", int(math.Ceil(e.RetryAfter.Minutes())))
+	}
+	return fmt.Sprintf("too many attempts, please try again in %d seconds", int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+// LockoutState is the failure history for one key
+type LockoutState struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+// LockoutStore persists failure counts; implementations must make RecordFailure atomic
+type LockoutStore interface {
+	Get(key string) (*LockoutState, error) // nil when the key has no history
+	RecordFailure(key string, resetAfter time.Duration) (*LockoutState, error)
+	Lock(key string, until time.Time) error
+	Reset(keys ...string) error
+}
+
+// LockoutService applies brute-force protection to authentication endpoints
+type LockoutService struct {
+	db     *gorm.DB
+	config *config.Config
+	store  LockoutStore
+}
+
+// NewLockoutService creates a lockout service backed by the store named in the config
+func NewLockoutService(db *gorm.DB, cfg *config.Config) *LockoutService {
+	var store LockoutStore
+	if cfg.AuthLockoutStore == "memory" {
+		store = sharedMemoryLockoutStore()
+	} else {
+		store = NewDBLockoutStore(db)
+	}
+
+	return &LockoutService{
+		db:     db,
+		config: cfg,
+		store:  store,
+	}
+}
+
+// Check returns a LockoutError if any of the keys is currently delayed or locked
+func (s *LockoutService) Check(keys ...string) error {
+	now := time.Now()
+	for _, key := range keys {
+		state, err := s.store.Get(key)
+		if err != nil {
+			return err
+		}
+		if state != nil && state.LockedUntil != nil && state.LockedUntil.After(now) {
+			return &LockoutError{
+				RetryAfter: state.LockedUntil.Sub(now),
+				Locked:     state.Failures >= lockoutPolicyFor(key).LockAfter,
+			}
+		}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed attempt and applies the policy's delay or lockout.
+// It reports whether this failure is the one that locked the key.
+func (s *LockoutService) RecordFailure(key string, policy LockoutPolicy) (bool, error) {
+	state, err := s.store.RecordFailure(key, policy.ResetAfter)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	switch {
+	case state.Failures >= policy.LockAfter:
+		return state.Failures == policy.LockAfter, s.store.Lock(key, now.Add(policy.LockDuration))
+	case state.Failures > policy.DelayAfter:
+		delay := time.Second << uint(state.Failures-policy.DelayAfter-1)
+		if delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+		return false, s.store.Lock(key, now.Add(delay))
+	}
+	return false, nil
+}
+
+// Throttle rejects the request if any key is delayed, then counts it against every key
+func (s *LockoutService) Throttle(policy LockoutPolicy, keys ...string) error {
+	if err := s.Check(keys...); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if _, err := s.RecordFailure(key, policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset clears the history of the given keys
+func (s *LockoutService) Reset(keys ...string) error {
+	return s.store.Reset(keys...)
+}
+
+// UnlockUser clears an account's failed login history (admin)
+func (s *LockoutService) UnlockUser(userID uint) error {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("user not found")
+		}
+		return err
+	}
+	return s.store.Reset(accountLockoutKey(user.Email))
+}
+
+// accountLockoutKey identifies an account in the lockout store
+func accountLockoutKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// ipLockoutKey identifies a client IP in the lockout store
+func ipLockoutKey(ip string) string {
+	return "ip:" + ip
+}
+
+// throttleKey identifies a rate-limited action for a subject such as an email or IP
+func throttleKey(action, subject string) string {
+	return action + ":" + strings.ToLower(strings.TrimSpace(subject))
+}
+
+// lockoutPolicyFor returns the policy that applies to a key
+func lockoutPolicyFor(key string) LockoutPolicy {
+	switch {
+	case strings.HasPrefix(key, "account:"), strings.HasPrefix(key, "phone:"):
+		return accountLockoutPolicy
+	case strings.HasPrefix(key, "ip:"):
+		return ipLockoutPolicy
+	default:
+		return requestThrottlePolicy
+	}
+}
+
+// DBLockoutStore keeps lockout state in the auth_lockouts table, shared by every instance
+type DBLockoutStore struct {
+	db *gorm.DB
+}
+
+// NewDBLockoutStore creates a database-backed lockout store
+func NewDBLockoutStore(db *gorm.DB) *DBLockoutStore {
+	return &DBLockoutStore{db: db}
+}
+
+// Get loads the state for a key
+func (d *DBLockoutStore) Get(key string) (*LockoutState, error) {
+	var row models.AuthLockout
+	if err := d.db.Where("key = ?", key).First(&row).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &LockoutState{Failures: row.Failures, LastFailureAt: row.LastFailureAt, LockedUntil: row.LockedUntil}, nil
+}
+
+// RecordFailure increments the failure count in a single upsert
+func (d *DBLockoutStore) RecordFailure(key string, resetAfter time.Duration) (*LockoutState, error) {
+	now := time.Now()
+	cutoff := now.Add(-resetAfter)
+	row := models.AuthLockout{Key: key, Failures: 1, LastFailureAt: now}
+	if err := d.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"failures":        gorm.Expr("CASE WHEN auth_lockouts.last_failure_at < ? THEN 1 ELSE auth_lockouts.failures + 1 END", cutoff),
+			"locked_until":    gorm.Expr("CASE WHEN auth_lockouts.last_failure_at < ? THEN NULL ELSE auth_lockouts.locked_until END", cutoff),
+			"last_failure_at": now,
+			"updated_at":      now,
+		}),
+	}, clause.Returning{}).Create(&row).Error; err != nil {
+		return nil, err
+	}
+	return &LockoutState{Failures: row.Failures, LastFailureAt: row.LastFailureAt, LockedUntil: row.LockedUntil}, nil
+}
+
+// Lock blocks a key until the given time
+func (d *DBLockoutStore) Lock(key string, until time.Time) error {
+	return d.db.Model(&models.AuthLockout{}).Where("key = ?", key).Update("locked_until", until).Error
+}
+
+// Reset deletes the state for the given keys
+func (d *DBLockoutStore) Reset(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return d.db.Where("key IN ?", keys).Delete(&models.AuthLockout{}).Error
+}
+
+// MemoryLockoutStore keeps lockout state in process memory; suitable for a single instance
+type MemoryLockoutStore struct {
+	mu      sync.Mutex
+	entries map[string]*LockoutState
+	writes  int
+}
+
+var (
+	memoryLockoutStore     *MemoryLockoutStore
+	memoryLockoutStoreOnce sync.Once
+)
+
+// sharedMemoryLockoutStore returns the process-wide store so every service sees the same state
+func sharedMemoryLockoutStore() *MemoryLockoutStore {
+	memoryLockoutStoreOnce.Do(func() {
+		memoryLockoutStore = NewMemoryLockoutStore()
+	})
+	return memoryLockoutStore
+}
+
+// NewMemoryLockoutStore creates an in-memory lockout store
+func NewMemoryLockoutStore() *MemoryLockoutStore {
+	return &MemoryLockoutStore{entries: make(map[string]*LockoutState)}
+}
+
+// Get returns a copy of the state for a key
+func (m *MemoryLockoutStore) Get(key string) (*LockoutState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *state
+	return &copied, nil
+}
+
+// RecordFailure increments the failure count for a key
+func (m *MemoryLockoutStore) RecordFailure(key string, resetAfter time.Duration) (*LockoutState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	state, ok := m.entries[key]
+	if !ok || now.Sub(state.LastFailureAt) > resetAfter {
+		state = &LockoutState{}
+		m.entries[key] = state
+	}
+	state.Failures++
+	state.LastFailureAt = now
+
+	m.writes++
+	if m.writes%1000 == 0 {
+		m.sweep(now)
+	}
+
+	copied := *state
+	return &copied, nil
+}
+
+// Lock blocks a key until the given time
+func (m *MemoryLockoutStore) Lock(key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if state, ok := m.entries[key]; ok {
+		state.LockedUntil = &until
+	}
+	return nil
+}
+
+// Reset deletes the state for the given keys
+func (m *MemoryLockoutStore) Reset(keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+// sweep drops entries that are no longer locked and have been quiet for a day
+func (m *MemoryLockoutStore) sweep(now time.Time) {
+	for key, state := range m.entries {
+		if (state.LockedUntil == nil || state.LockedUntil.Before(now)) && now.Sub(state.LastFailureAt) > 24*time.Hour {
+			delete(m.entries, key)
+		}
+	}
+}
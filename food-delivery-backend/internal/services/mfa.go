@@ -0,0 +1,424 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"kenyan-food-delivery/internal/auth"
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+	"kenyan-food-delivery/pkg/totp"
+
+	"gorm.io/gorm"
+)
+
+// Two-factor authentication rules
+const (
+	mfaIssuer            = "Kenyan Food Delivery" // Shown next to the account in authenticator apps
+	mfaClockSkew         = 1                      // Accept codes one step either side of now
+	mfaRecoveryCodeCount = 10
+)
+
+// errInvalidMFACode is returned for any code that doesn't verify, so callers learn nothing more
+var errInvalidMFACode = errors.New("invalid authentication code")
+
+// errInvalidMFAPassword is returned when the password re-entered to change 2FA settings is wrong
+var errInvalidMFAPassword = errors.New("invalid password")
+
+// MFAService manages TOTP authenticator enrolment and recovery codes
+type MFAService struct {
+	db     *gorm.DB
+	config *config.Config
+	rbac   *RBACService
+}
+
+// NewMFAService creates a new MFA service
+func NewMFAService(db *gorm.DB, cfg *config.Config) *MFAService {
+	return &MFAService{
+		db:     db,
+		config: cfg,
+		rbac:   NewRBACService(db, cfg),
+	}
+}
+
+// MFACodeRequest carries a code from an authenticator app
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableMFARequest confirms turning two-factor authentication off
+type DisableMFARequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"` // Authenticator or recovery code
+}
+
+// MFASetup is a new authenticator secret waiting to be confirmed
+type MFASetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // Render as a QR code
+}
+
+// MFAStatus describes a user's two-factor authentication state
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	Required               bool       `json:"required"`
+	EnabledAt              *time.Time `json:"enabled_at"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+// Status returns the user's two-factor authentication state
+func (s *MFAService) Status(userID uint) (*MFAStatus, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	required, err := s.Required(user)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MFAStatus{Enabled: user.TwoFactorEnabled, Required: required}
+	if !user.TwoFactorEnabled {
+		return status, nil
+	}
+
+	var enrolment models.UserMFA
+	if err := s.db.First(&enrolment, "user_id = ?", userID).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	status.EnabledAt = enrolment.ConfirmedAt
+
+	if err := s.db.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&status.RecoveryCodesRemaining).Error; err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Required reports whether policy makes two-factor authentication mandatory for the user:
+// admins, super admins and anyone holding another platform-wide role
+func (s *MFAService) Required(user *models.User) (bool, error) {
+	if user.Role == models.RoleAdmin || user.Role == models.RoleSuperAdmin {
+		return true, nil
+	}
+	return s.rbac.HasGlobalRole(user.ID)
+}
+
+// Setup generates a new authenticator secret; it takes effect once Enable confirms a code from it
+func (s *MFAService) Setup(userID uint) (*MFASetup, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := s.encryptSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replace any earlier setup that was never confirmed
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserMFA{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.UserMFA{UserID: userID, SecretCiphertext: ciphertext}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MFASetup{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(mfaIssuer, user.Email, secret),
+	}, nil
+}
+
+// Enable confirms a pending setup with a code from the authenticator and returns a fresh
+// set of recovery codes, which are only ever shown this once
+func (s *MFAService) Enable(userID uint, code string) ([]string, error) {
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var enrolment models.UserMFA
+		if err := tx.First(&enrolment, "user_id = ?", userID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.New("two-factor authentication has not been set up")
+			}
+			return err
+		}
+		if enrolment.ConfirmedAt != nil {
+			return errors.New("two-factor authentication is already enabled")
+		}
+
+		step, err := s.validateTOTP(&enrolment, code)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&enrolment).Updates(map[string]interface{}{
+			"confirmed_at":   now,
+			"last_used_step": step,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("two_factor_enabled", true).Error; err != nil {
+			return err
+		}
+
+		codes, err = s.replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off after re-checking the password and a code
+func (s *MFAService) Disable(userID uint, req *DisableMFARequest) error {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	required, err := s.Required(user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return errors.New("two-factor authentication is required for your role")
+	}
+
+	if err := auth.CheckPassword(req.Password, user.Password); err != nil {
+		return errInvalidMFAPassword
+	}
+	if err := s.Verify(userID, req.Code); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserMFA{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", userID).Update("two_factor_enabled", false).Error
+	})
+}
+
+// RegenerateRecoveryCodes replaces every recovery code after checking an authenticator code
+func (s *MFAService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	enrolment, err := s.confirmedEnrolment(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.consumeTOTP(enrolment, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		codes, err = s.replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks an authenticator code, or failing that a recovery code, for a user with
+// two-factor authentication enabled. Each code can only be used once.
+func (s *MFAService) Verify(userID uint, code string) error {
+	enrolment, err := s.confirmedEnrolment(userID)
+	if err != nil {
+		return err
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return s.consumeTOTP(enrolment, code)
+	}
+	return s.consumeRecoveryCode(userID, code)
+}
+
+// confirmedEnrolment loads a user's active authenticator
+func (s *MFAService) confirmedEnrolment(userID uint) (*models.UserMFA, error) {
+	var enrolment models.UserMFA
+	if err := s.db.First(&enrolment, "user_id = ? AND confirmed_at IS NOT NULL", userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("two-factor authentication is not enabled")
+		}
+		return nil, err
+	}
+	return &enrolment, nil
+}
+
+// consumeTOTP accepts an authenticator code unless it, or a later one, has already been used
+func (s *MFAService) consumeTOTP(enrolment *models.UserMFA, code string) error {
+	step, err := s.validateTOTP(enrolment, code)
+	if err != nil {
+		return err
+	}
+
+	// The conditional update stops two requests racing to use the same code
+	result := s.db.Model(&models.UserMFA{}).
+		Where("user_id = ? AND last_used_step < ?", enrolment.UserID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errInvalidMFACode
+	}
+	return nil
+}
+
+// validateTOTP checks a code against the enrolment's secret and returns its time step
+func (s *MFAService) validateTOTP(enrolment *models.UserMFA, code string) (int64, error) {
+	secret, err := s.decryptSecret(enrolment.SecretCiphertext)
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), mfaClockSkew)
+	if !ok || step <= enrolment.LastUsedStep {
+		return 0, errInvalidMFACode
+	}
+	return step, nil
+}
+
+// consumeRecoveryCode marks a matching unused recovery code as used
+func (s *MFAService) consumeRecoveryCode(userID uint, code string) error {
+	result := s.db.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, s.hashRecoveryCode(userID, code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errInvalidMFACode
+	}
+	return nil
+}
+
+// replaceRecoveryCodes deletes a user's recovery codes and issues a new set
+func (s *MFAService) replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, mfaRecoveryCodeCount)
+	records := make([]models.MFARecoveryCode, 0, mfaRecoveryCodeCount)
+	for i := 0; i < mfaRecoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.MFARecoveryCode{UserID: userID, CodeHash: s.hashRecoveryCode(userID, code)})
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode hashes a recovery code for storage, ignoring case, spaces and dashes
+func (s *MFAService) hashRecoveryCode(userID uint, code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	mac := hmac.New(sha256.New, []byte(s.config.JWTSecret))
+	mac.Write([]byte(fmt.Sprintf("mfa-recovery|%d|%s", userID, normalized)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// encryptSecret seals an authenticator secret so a database leak alone can't generate codes
+func (s *MFAService) encryptSecret(secret string) (string, error) {
+	gcm, err := s.secretCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecret opens a secret sealed by encryptSecret
+func (s *MFAService) decryptSecret(ciphertext string) (string, error) {
+	gcm, err := s.secretCipher()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", errors.New("stored authenticator secret is corrupt")
+	}
+	secret, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("stored authenticator secret cannot be decrypted")
+	}
+	return string(secret), nil
+}
+
+// secretCipher derives the AES-256-GCM cipher for authenticator secrets from the JWT secret
+func (s *MFAService) secretCipher() (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte("totp|" + s.config.JWTSecret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// findUser loads a user by ID
+func (s *MFAService) findUser(userID uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// generateRecoveryCode returns a code like "k7mqp-2xw9d" from an alphabet without look-alike characters
+func generateRecoveryCode() (string, error) {
+	alphabet := strings.ToLower(inviteCodeAlphabet)
+	bytes := make([]byte, 10)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	for i, b := range bytes {
+		bytes[i] = alphabet[int(b)%len(alphabet)]
+	}
+	return string(bytes[:5]) + "-" + string(bytes[5:]), nil
+}
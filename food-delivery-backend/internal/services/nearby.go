@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"kenyan-food-delivery/internal/models"
+	"kenyan-food-delivery/pkg/location"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	kmPerDegreeLatitude    = 111.32
+	defaultPrepMinutes     = 20
+	travelMinutesPerKm     = 3.0 // Roughly 20 km/h through city traffic
+	maxNearbyCandidateRows = 500
+)
+
+// Errors for nearby searches the client can correct
+var (
+	ErrInvalidCoordinates   = errors.New("invalid coordinates")
+	ErrAddressNotFound      = errors.New("address not found")
+	ErrAddressNoCoordinates = errors.New("address has no coordinates")
+)
+
+// NearbyRestaurant represents a restaurant that delivers to a location
+type NearbyRestaurant struct {
+	Restaurant           models.Restaurant `json:"restaurant"`
+	DistanceKm           float64           `json:"distance_km"`
+	EstimatedDeliveryMin int               `json:"estimated_delivery_minutes"`
+	DeliveryFee          float64           `json:"delivery_fee"`
+}
+
+// GetNearbyRestaurants returns approved restaurants whose delivery radius covers the location, nearest first.
+// Candidates are narrowed with a bounding box on the indexed latitude/longitude columns and
+// capped nearest first, so in dense areas only the farthest are dropped, before exact
+// distances are computed.
+func (s *RestaurantService) GetNearbyRestaurants(lat, lon float64, page, limit int) ([]NearbyRestaurant, int64, error) {
+	if !validCoordinates(lat, lon) {
+		return nil, 0, ErrInvalidCoordinates
+	}
+
+	maxRadius := s.config.MaxDeliveryRadius
+	latDelta := maxRadius / kmPerDegreeLatitude
+	lonDelta := maxRadius / (kmPerDegreeLatitude * math.Max(math.Cos(lat*math.Pi/180), 0.01))
+
+	var candidates []models.Restaurant
+	if err := s.db.Where("status = ?", models.RestaurantStatusApproved).
+		Where("latitude BETWEEN ? AND ?", lat-latDelta, lat+latDelta).
+		Where("longitude BETWEEN ? AND ?", lon-lonDelta, lon+lonDelta).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "power(latitude - ?, 2) + power((longitude - ?) * cos(radians(?)), 2) ASC",
+			Vars:               []interface{}{lat, lon, lat},
+			WithoutParentheses: true,
+		}}).
+		Limit(maxNearbyCandidateRows).
+		Find(&candidates).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var nearby []NearbyRestaurant
+	for _, restaurant := range candidates {
+		distance := location.CalculateDistance(restaurant.Latitude, restaurant.Longitude, lat, lon)
+		if distance > s.deliveryRadius(&restaurant) {
+			continue
+		}
+
+		nearby = append(nearby, NearbyRestaurant{
+			Restaurant:           restaurant,
+			DistanceKm:           math.Round(distance*100) / 100,
+			EstimatedDeliveryMin: estimateDeliveryMinutes(&restaurant, distance),
+			DeliveryFee:          s.quoteDeliveryFee(&restaurant, distance),
+		})
+	}
+
+	sort.Slice(nearby, func(i, j int) bool {
+		return nearby[i].DistanceKm < nearby[j].DistanceKm
+	})
+
+	total := int64(len(nearby))
+	start := (page - 1) * limit
+	if start < 0 || start >= len(nearby) {
+		return []NearbyRestaurant{}, total, nil
+	}
+	end := start + limit
+	if end > len(nearby) {
+		end = len(nearby)
+	}
+	results := nearby[start:end]
+
+	restaurantIDs := make([]uint, 0, len(results))
+	for _, item := range results {
+		restaurantIDs = append(restaurantIDs, item.Restaurant.ID)
+	}
+	var detailed []models.Restaurant
+	if err := s.db.Preload("Categories").Preload("Cuisines").
+		Where("id IN ?", restaurantIDs).Find(&detailed).Error; err != nil {
+		return nil, 0, err
+	}
+	detailedByID := make(map[uint]models.Restaurant, len(detailed))
+	for _, restaurant := range detailed {
+		detailedByID[restaurant.ID] = restaurant
+	}
+	for i := range results {
+		if restaurant, ok := detailedByID[results[i].Restaurant.ID]; ok {
+			results[i].Restaurant = restaurant
+		}
+	}
+	if err := annotateNearby(s.db, results); err != nil {
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
+
+// annotateNearby sets each result's availability and adds any busy mode delay to its estimate
+func annotateNearby(db *gorm.DB, results []NearbyRestaurant) error {
+	restaurants := make([]models.Restaurant, len(results))
+	for i := range results {
+		restaurants[i] = results[i].Restaurant
+	}
+	if err := annotateAvailability(db, restaurants); err != nil {
+		return err
+	}
+	for i := range results {
+		results[i].Restaurant.Availability = restaurants[i].Availability
+		results[i].EstimatedDeliveryMin += restaurants[i].Availability.ExtraPrepMinutes
+	}
+	return nil
+}
+
+// GetNearbyRestaurantsForAddress runs a nearby search from one of the user's saved addresses
+func (s *RestaurantService) GetNearbyRestaurantsForAddress(userID, addressID uint, page, limit int) ([]NearbyRestaurant, int64, error) {
+	var address models.Address
+	if err := s.db.Where("id = ? AND user_id = ?", addressID, userID).First(&address).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, 0, ErrAddressNotFound
+		}
+		return nil, 0, err
+	}
+
+	if address.Latitude == 0 && address.Longitude == 0 {
+		return nil, 0, ErrAddressNoCoordinates
+	}
+
+	return s.GetNearbyRestaurants(address.Latitude, address.Longitude, page, limit)
+}
+
+// deliveryRadius returns the radius a restaurant delivers within, capped at the platform maximum
+func (s *RestaurantService) deliveryRadius(restaurant *models.Restaurant) float64 {
+	if restaurant.DeliveryRadius > 0 && restaurant.DeliveryRadius < s.config.MaxDeliveryRadius {
+		return restaurant.DeliveryRadius
+	}
+	return s.config.MaxDeliveryRadius
+}
+
+// quoteDeliveryFee prices delivery from the restaurant's base fee and the distance travelled
+func (s *RestaurantService) quoteDeliveryFee(restaurant *models.Restaurant, distance float64) float64 {
+	if restaurant.FreeDelivery {
+		return 0
+	}
+
+	baseFee := restaurant.DeliveryFee
+	if baseFee == 0 {
+		baseFee = s.config.DefaultDeliveryFee
+	}
+	fee := location.CalculateDeliveryFee(distance, &location.DeliveryZone{DeliveryFee: baseFee})
+	return math.Round(fee)
+}
+
+// estimateDeliveryMinutes adds travel time to a typical preparation time, never quoting
+// faster than the restaurant's own average delivery time
+func estimateDeliveryMinutes(restaurant *models.Restaurant, distance float64) int {
+	estimate := defaultPrepMinutes + int(math.Ceil(distance*travelMinutesPerKm))
+	if restaurant.DeliveryTime > estimate {
+		return restaurant.DeliveryTime
+	}
+	return estimate
+}
+
+// validCoordinates reports whether lat and lon are finite and within ±90 and ±180 degrees.
+// NaN fails every comparison, so it is rejected by the range checks too.
+func validCoordinates(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
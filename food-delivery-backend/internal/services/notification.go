@@ -0,0 +1,403 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+	"kenyan-food-delivery/internal/templates"
+
+	"gorm.io/gorm"
+)
+
+// Notification list page sizes
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+)
+
+// NotificationService stores in-app notifications and serves the user's inbox
+type NotificationService struct {
+	db     *gorm.DB
+	config *config.Config
+	email  *EmailService
+	outbox *OutboxService
+	push   *PushService
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(db *gorm.DB, cfg *config.Config) *NotificationService {
+	return &NotificationService{
+		db:     db,
+		config: cfg,
+		email:  NewEmailService(db, cfg),
+		outbox: NewOutboxService(db, cfg),
+		push:   NewPushService(db, cfg),
+	}
+}
+
+// NotificationQuery selects a page of a user's notifications, newest first
+type NotificationQuery struct {
+	Cursor     string `form:"cursor"` // next_cursor from the previous page
+	Limit      int    `form:"limit"`
+	UnreadOnly bool   `form:"unread"`
+}
+
+// NotificationPage is one page of a user's inbox
+type NotificationPage struct {
+	Notifications []models.Notification `json:"notifications"`
+	UnreadCount   int64                 `json:"unread_count"`
+	NextCursor    string                `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// NotificationMessage is a notification to send on every channel the user has enabled for its type
+type NotificationMessage struct {
+	Type    models.NotificationType
+	Title   string
+	Message string                 // Used for in-app, push and SMS, and as the email text
+	Data    map[string]interface{} // IDs to deep-link to
+
+	// Template names a notification template rendered with TemplateData in the user's
+	// preferred language; when set it replaces Title and Message
+	Template     string
+	TemplateData map[string]interface{}
+
+	Email *templates.Email // Optional email replacing the standard notification email
+}
+
+// Dispatch sends a notification to a user, honouring their channel preferences and quiet
+// hours. The in-app notification is stored before it returns; email, SMS and push are sent
+// in the background. Security notifications ignore preferences and quiet hours.
+func (s *NotificationService) Dispatch(userID uint, message *NotificationMessage) error {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("user not found")
+		}
+		return err
+	}
+
+	if message.Template != "" {
+		rendered, err := templates.RenderNotification(user.PreferredLanguage, message.Template, message.TemplateData)
+		if err != nil {
+			return err
+		}
+		localized := *message
+		localized.Title, localized.Message = rendered.Title, rendered.Message
+		message = &localized
+	}
+
+	channels, err := s.channelPreferences(userID, message.Type)
+	if err != nil {
+		return err
+	}
+
+	if message.Type != models.NotificationTypeSecurity {
+		quiet, err := s.inQuietHours(userID, time.Now())
+		if err != nil {
+			return err
+		}
+		if quiet {
+			channels[models.NotificationChannelPush] = false
+			channels[models.NotificationChannelSMS] = false
+		}
+	}
+
+	if channels[models.NotificationChannelInApp] {
+		if _, err := s.Notify(userID, message.Type, message.Title, message.Message, message.Data); err != nil {
+			return err
+		}
+	}
+
+	go s.deliver(&user, message, channels)
+	return nil
+}
+
+// deliver sends a dispatched notification on the external channels, logging failures
+func (s *NotificationService) deliver(user *models.User, message *NotificationMessage, channels map[models.NotificationChannel]bool) {
+	if channels[models.NotificationChannelEmail] && user.Email != "" {
+		var headers map[string]string
+		unsubscribeURL := ""
+		if message.Type == models.NotificationTypePromotion {
+			unsubscribeURL = s.UnsubscribeURL(user.ID, message.Type, models.NotificationChannelEmail)
+			headers = map[string]string{
+				"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+				"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+			}
+		}
+
+		email := message.Email
+		var err error
+		if email == nil {
+			email, err = s.email.NotificationEmail(user, message.Title, message.Message, unsubscribeURL)
+		}
+		if err == nil {
+			err = s.email.Send(user.Email, email, headers)
+		}
+		if err != nil {
+			log.Printf("Failed to email %s notification to user %d: %v", message.Type, user.ID, err)
+		}
+	}
+
+	if channels[models.NotificationChannelSMS] && user.PhoneNumber != "" && user.PhoneVerifiedAt != nil {
+		if err := s.outbox.EnqueueSMS(user.PhoneNumber, message.Message, smsCategoryFor(message.Type), false); err != nil {
+			log.Printf("Failed to text %s notification to user %d: %v", message.Type, user.ID, err)
+		}
+	}
+
+	if channels[models.NotificationChannelPush] {
+		data := map[string]string{"type": string(message.Type)}
+		for key, value := range message.Data {
+			data[key] = fmt.Sprint(value)
+		}
+		if _, err := s.push.SendToUser(user.ID, &PushMessage{Title: message.Title, Body: message.Message, Data: data}); err != nil {
+			log.Printf("Failed to push %s notification to user %d: %v", message.Type, user.ID, err)
+		}
+	}
+}
+
+// smsCategoryFor groups notification texts for SMS cost reporting
+func smsCategoryFor(notificationType models.NotificationType) string {
+	switch notificationType {
+	case models.NotificationTypeOrder, models.NotificationTypePayment, models.NotificationTypeDelivery:
+		return SMSCategoryOrderUpdate
+	case models.NotificationTypeSecurity:
+		return SMSCategorySecurity
+	default:
+		return SMSCategoryNotification
+	}
+}
+
+// Notify adds a notification to a user's inbox without consulting preferences; most callers
+// want Dispatch. data is stored as JSON for clients to deep-link from, e.g. {"order_id": 12}.
+func (s *NotificationService) Notify(userID uint, notificationType models.NotificationType, title, message string, data map[string]interface{}) (*models.Notification, error) {
+	notification := models.Notification{
+		UserID:  userID,
+		Type:    notificationType,
+		Title:   title,
+		Message: message,
+	}
+	if len(data) > 0 {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		notification.Data = string(encoded)
+	}
+
+	if err := s.db.Create(&notification).Error; err != nil {
+		return nil, err
+	}
+
+	return &notification, nil
+}
+
+// List returns a page of the user's notifications with their unread count
+func (s *NotificationService) List(userID uint, query *NotificationQuery) (*NotificationPage, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultNotificationPageSize
+	}
+	if limit > maxNotificationPageSize {
+		limit = maxNotificationPageSize
+	}
+
+	db := s.db.Where("user_id = ?", userID)
+	if query.UnreadOnly {
+		db = db.Where("is_read = ?", false)
+	}
+	if query.Cursor != "" {
+		cursor, err := strconv.ParseUint(query.Cursor, 10, 32)
+		if err != nil {
+			return nil, errors.New("invalid cursor")
+		}
+		db = db.Where("id < ?", cursor)
+	}
+
+	// Fetch one extra row to learn whether there is another page
+	var notifications []models.Notification
+	if err := db.Order("id DESC").Limit(limit + 1).Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+
+	page := &NotificationPage{Notifications: notifications}
+	if len(notifications) > limit {
+		page.Notifications = notifications[:limit]
+		page.NextCursor = strconv.FormatUint(uint64(page.Notifications[limit-1].ID), 10)
+	}
+
+	unread, err := s.UnreadCount(userID)
+	if err != nil {
+		return nil, err
+	}
+	page.UnreadCount = unread
+	return page, nil
+}
+
+// UnreadCount counts the user's unread notifications
+func (s *NotificationService) UnreadCount(userID uint) (int64, error) {
+	var count int64
+	err := s.db.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&count).Error
+	return count, err
+}
+
+// MarkRead marks one of the user's notifications as read
+func (s *NotificationService) MarkRead(userID, notificationID uint) (*models.Notification, error) {
+	var notification models.Notification
+	if err := s.db.Where("id = ? AND user_id = ?", notificationID, userID).First(&notification).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("notification not found")
+		}
+		return nil, err
+	}
+
+	if notification.IsRead {
+		return &notification, nil
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&notification).Updates(map[string]interface{}{"is_read": true, "read_at": now}).Error; err != nil {
+		return nil, err
+	}
+
+	return &notification, nil
+}
+
+// MarkAllRead marks every unread notification of the user as read, returning how many changed
+func (s *NotificationService) MarkAllRead(userID uint) (int64, error) {
+	result := s.db.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// Delete removes one of the user's notifications
+func (s *NotificationService) Delete(userID, notificationID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", notificationID, userID).Delete(&models.Notification{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("notification not found")
+	}
+
+	return nil
+}
+
+// CleanupExpired permanently deletes notifications older than the retention period,
+// including ones users have already deleted
+func (s *NotificationService) CleanupExpired() (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -s.config.NotificationRetentionDays)
+	result := s.db.Unscoped().Where("created_at < ?", cutoff).Delete(&models.Notification{})
+	return result.RowsAffected, result.Error
+}
+
+// RunCleanup calls CleanupExpired every interval; it never returns
+func (s *NotificationService) RunCleanup(interval time.Duration) {
+	runPeriodically(interval, "expired notifications", s.CleanupExpired)
+}
+
+// NotifyOrderPlaced tells the customer their order was received and the restaurant owner
+// that a new order is waiting
+func (s *NotificationService) NotifyOrderPlaced(order *models.Order) {
+	data := map[string]interface{}{"order_id": order.ID, "order_number": order.OrderNumber}
+
+	s.notifyOrLog(order.UserID, models.NotificationTypeOrder, "order_placed",
+		map[string]interface{}{"OrderNumber": order.OrderNumber}, data)
+
+	var ownerIDs []uint
+	if err := s.db.Model(&models.Restaurant{}).Where("id = ?", order.RestaurantID).Pluck("owner_id", &ownerIDs).Error; err != nil {
+		log.Printf("Failed to find owner of restaurant %d: %v", order.RestaurantID, err)
+		return
+	}
+	for _, ownerID := range ownerIDs {
+		s.notifyOrLog(ownerID, models.NotificationTypeOrder, "order_received",
+			map[string]interface{}{"OrderNumber": order.OrderNumber, "Amount": order.TotalAmount}, data)
+	}
+}
+
+// NotifyOrderStatus tells the customer their order moved to a new status
+func (s *NotificationService) NotifyOrderStatus(order *models.Order) {
+	name, ok := orderStatusTemplates[order.Status]
+	if !ok {
+		name = "order_status"
+	}
+	vars := map[string]interface{}{
+		"OrderNumber": order.OrderNumber,
+		"Status":      strings.ReplaceAll(string(order.Status), "_", " "),
+	}
+	if order.Status == models.OrderStatusCancelled {
+		vars["Reason"] = order.CancelReason
+	}
+
+	s.notifyOrLog(order.UserID, models.NotificationTypeOrder, name, vars,
+		map[string]interface{}{"order_id": order.ID, "order_number": order.OrderNumber, "status": order.Status})
+}
+
+// NotifyPaymentStatus tells the payer whether their payment went through
+func (s *NotificationService) NotifyPaymentStatus(payment *models.Payment) {
+	var name string
+	amount := payment.Amount
+	switch payment.Status {
+	case models.PaymentStatusCompleted:
+		name = "payment_completed"
+	case models.PaymentStatusFailed:
+		name = "payment_failed"
+	case models.PaymentStatusRefunded:
+		name, amount = "payment_refunded", payment.RefundAmount
+	default:
+		return
+	}
+
+	s.notifyOrLog(payment.UserID, models.NotificationTypePayment, name, map[string]interface{}{"Amount": amount},
+		map[string]interface{}{"order_id": payment.OrderID, "payment_id": payment.ID, "status": payment.Status})
+}
+
+// NotifyDeliveryStatus tells the customer where their delivery is
+func (s *NotificationService) NotifyDeliveryStatus(delivery *models.Delivery, customerID uint) {
+	name, ok := deliveryStatusTemplates[delivery.Status]
+	if !ok {
+		return
+	}
+
+	s.notifyOrLog(customerID, models.NotificationTypeDelivery, name, nil,
+		map[string]interface{}{"order_id": delivery.OrderID, "delivery_id": delivery.ID, "status": delivery.Status, "tracking_code": delivery.TrackingCode})
+}
+
+// orderStatusTemplates name the notifications shown to customers; other statuses use "order_status"
+var orderStatusTemplates = map[models.OrderStatus]string{
+	models.OrderStatusConfirmed: "order_confirmed",
+	models.OrderStatusPreparing: "order_preparing",
+	models.OrderStatusReady:     "order_ready",
+	models.OrderStatusPickedUp:  "order_picked_up",
+	models.OrderStatusDelivered: "order_delivered",
+	models.OrderStatusCancelled: "order_cancelled",
+	models.OrderStatusRefunded:  "order_refunded",
+}
+
+// deliveryStatusTemplates name the notifications shown to customers as their delivery progresses
+var deliveryStatusTemplates = map[models.DeliveryStatus]string{
+	models.DeliveryStatusAssigned:  "delivery_assigned",
+	models.DeliveryStatusPickedUp:  "delivery_picked_up",
+	models.DeliveryStatusInTransit: "delivery_in_transit",
+	models.DeliveryStatusDelivered: "delivery_delivered",
+	models.DeliveryStatusFailed:    "delivery_failed",
+}
+
+// notifyOrLog dispatches a templated notification from an event handler, where a failure must not undo the event
+func (s *NotificationService) notifyOrLog(userID uint, notificationType models.NotificationType, template string, vars, data map[string]interface{}) {
+	err := s.Dispatch(userID, &NotificationMessage{Type: notificationType, Template: template, TemplateData: vars, Data: data})
+	if err != nil {
+		log.Printf("Failed to notify user %d: %v", userID, err)
+	}
+}
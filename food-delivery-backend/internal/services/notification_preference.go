@@ -0,0 +1,341 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// notificationTypes are the types users can set preferences for, in display order
+var notificationTypes = []models.NotificationType{
+	models.NotificationTypeOrder,
+	models.NotificationTypePayment,
+	models.NotificationTypeDelivery,
+	models.NotificationTypePromotion,
+	models.NotificationTypeSystem,
+	models.NotificationTypeSecurity,
+}
+
+// notificationChannels are the channels notifications can be sent on, in display order
+var notificationChannels = []models.NotificationChannel{
+	models.NotificationChannelInApp,
+	models.NotificationChannelPush,
+	models.NotificationChannelEmail,
+	models.NotificationChannelSMS,
+}
+
+// defaultChannelPreferences apply until a user changes them. Security notifications aren't
+// listed because they go out on every channel regardless.
+var defaultChannelPreferences = map[models.NotificationType]map[models.NotificationChannel]bool{
+	models.NotificationTypeOrder:     {models.NotificationChannelInApp: true, models.NotificationChannelPush: true},
+	models.NotificationTypePayment:   {models.NotificationChannelInApp: true, models.NotificationChannelPush: true, models.NotificationChannelEmail: true},
+	models.NotificationTypeDelivery:  {models.NotificationChannelInApp: true, models.NotificationChannelPush: true},
+	models.NotificationTypePromotion: {models.NotificationChannelInApp: true, models.NotificationChannelEmail: true},
+	models.NotificationTypeSystem:    {models.NotificationChannelInApp: true, models.NotificationChannelEmail: true},
+}
+
+// ChannelPreference is whether one type of notification is sent on one channel
+type ChannelPreference struct {
+	Type    models.NotificationType    `json:"type"`
+	Channel models.NotificationChannel `json:"channel"`
+	Enabled bool                       `json:"enabled"`
+	Locked  bool                       `json:"locked"` // Can't be changed, e.g. security notifications
+}
+
+// QuietHours is the daily window in which push and SMS notifications are held back
+type QuietHours struct {
+	Enabled  bool   `json:"enabled"`
+	Start    string `json:"start"` // HH:MM
+	End      string `json:"end"`
+	Timezone string `json:"timezone"`
+}
+
+// NotificationPreferences is a user's full preference matrix
+type NotificationPreferences struct {
+	Preferences []ChannelPreference `json:"preferences"`
+	QuietHours  QuietHours          `json:"quiet_hours"`
+}
+
+// ChannelPreferenceUpdate turns one type of notification on or off for one channel
+type ChannelPreferenceUpdate struct {
+	Type    models.NotificationType    `json:"type" binding:"required"`
+	Channel models.NotificationChannel `json:"channel" binding:"required,oneof=in_app push email sms"`
+	Enabled *bool                      `json:"enabled" binding:"required"`
+}
+
+// QuietHoursRequest sets a user's quiet hours
+type QuietHoursRequest struct {
+	Enabled bool   `json:"enabled"`
+	Start   string `json:"start"` // HH:MM, required when enabled
+	End     string `json:"end"`
+}
+
+// UpdateNotificationPreferencesRequest changes some of a user's preferences; anything left out stays as it is
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []ChannelPreferenceUpdate `json:"preferences" binding:"dive"`
+	QuietHours  *QuietHoursRequest        `json:"quiet_hours"`
+}
+
+// GetPreferences returns the user's preference for every type and channel, with defaults filled in
+func (s *NotificationService) GetPreferences(userID uint) (*NotificationPreferences, error) {
+	var stored []models.NotificationPreference
+	if err := s.db.Where("user_id = ?", userID).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+	overrides := make(map[models.NotificationType]map[models.NotificationChannel]bool)
+	for _, preference := range stored {
+		if overrides[preference.Type] == nil {
+			overrides[preference.Type] = make(map[models.NotificationChannel]bool)
+		}
+		overrides[preference.Type][preference.Channel] = preference.Enabled
+	}
+
+	result := &NotificationPreferences{}
+	for _, notificationType := range notificationTypes {
+		for _, channel := range notificationChannels {
+			result.Preferences = append(result.Preferences, ChannelPreference{
+				Type:    notificationType,
+				Channel: channel,
+				Enabled: channelEnabled(notificationType, channel, overrides[notificationType]),
+				Locked:  notificationType == models.NotificationTypeSecurity,
+			})
+		}
+	}
+
+	settings, err := s.settings(userID)
+	if err != nil {
+		return nil, err
+	}
+	result.QuietHours = QuietHours{
+		Enabled:  settings.QuietHoursEnabled,
+		Start:    settings.QuietHoursStart,
+		End:      settings.QuietHoursEnd,
+		Timezone: "Africa/Nairobi",
+	}
+	return result, nil
+}
+
+// UpdatePreferences saves changed preferences and quiet hours, returning the full result
+func (s *NotificationService) UpdatePreferences(userID uint, req *UpdateNotificationPreferencesRequest) (*NotificationPreferences, error) {
+	for _, update := range req.Preferences {
+		if err := validatePreferenceUpdate(&update); err != nil {
+			return nil, err
+		}
+	}
+	if req.QuietHours != nil && req.QuietHours.Enabled {
+		if err := validateQuietHours(req.QuietHours.Start, req.QuietHours.End); err != nil {
+			return nil, err
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, update := range req.Preferences {
+			if update.Type == models.NotificationTypeSecurity {
+				continue // Always on
+			}
+			if err := setPreference(tx, userID, update.Type, update.Channel, *update.Enabled); err != nil {
+				return err
+			}
+		}
+
+		if req.QuietHours != nil {
+			settings := models.NotificationSettings{
+				UserID:            userID,
+				QuietHoursEnabled: req.QuietHours.Enabled,
+				QuietHoursStart:   req.QuietHours.Start,
+				QuietHoursEnd:     req.QuietHours.End,
+			}
+			if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&settings).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPreferences(userID)
+}
+
+// channelPreferences returns which channels the user wants a notification type sent on
+func (s *NotificationService) channelPreferences(userID uint, notificationType models.NotificationType) (map[models.NotificationChannel]bool, error) {
+	var stored []models.NotificationPreference
+	if notificationType != models.NotificationTypeSecurity {
+		if err := s.db.Where("user_id = ? AND type = ?", userID, notificationType).Find(&stored).Error; err != nil {
+			return nil, err
+		}
+	}
+	overrides := make(map[models.NotificationChannel]bool)
+	for _, preference := range stored {
+		overrides[preference.Channel] = preference.Enabled
+	}
+
+	enabled := make(map[models.NotificationChannel]bool)
+	for _, channel := range notificationChannels {
+		enabled[channel] = channelEnabled(notificationType, channel, overrides)
+	}
+	return enabled, nil
+}
+
+// inQuietHours reports whether t falls in the user's quiet hours
+func (s *NotificationService) inQuietHours(userID uint, t time.Time) (bool, error) {
+	settings, err := s.settings(userID)
+	if err != nil {
+		return false, err
+	}
+	if !settings.QuietHoursEnabled {
+		return false, nil
+	}
+
+	start, errStart := time.Parse("15:04", settings.QuietHoursStart)
+	end, errEnd := time.Parse("15:04", settings.QuietHoursEnd)
+	if errStart != nil || errEnd != nil {
+		return false, nil
+	}
+
+	local := t.In(eastAfricaTime)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	if startMinute < endMinute {
+		return minute >= startMinute && minute < endMinute, nil
+	}
+	// The window wraps past midnight, e.g. 22:00 to 07:00
+	return minute >= startMinute || minute < endMinute, nil
+}
+
+// settings returns the user's notification settings, or the defaults if they have none
+func (s *NotificationService) settings(userID uint) (*models.NotificationSettings, error) {
+	var settings models.NotificationSettings
+	if err := s.db.Where("user_id = ?", userID).First(&settings).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &models.NotificationSettings{UserID: userID}, nil
+		}
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// UnsubscribeURL returns a link that turns off one type of notification on one channel
+// without signing in. Links don't expire, as old emails must keep working.
+func (s *NotificationService) UnsubscribeURL(userID uint, notificationType models.NotificationType, channel models.NotificationChannel) string {
+	payload := fmt.Sprintf("%d:%s:%s", userID, notificationType, channel)
+	token := base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + s.signUnsubscribe(payload)
+	return fmt.Sprintf("%s/api/v1/notifications/unsubscribe?token=%s", s.config.BackendURL, url.QueryEscape(token))
+}
+
+// Unsubscribe turns off the preference named in an unsubscribe link token
+func (s *NotificationService) Unsubscribe(token string) (*ChannelPreference, error) {
+	invalid := errors.New("invalid unsubscribe link")
+
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return nil, invalid
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, invalid
+	}
+	payload := string(decoded)
+	if !hmac.Equal([]byte(signature), []byte(s.signUnsubscribe(payload))) {
+		return nil, invalid
+	}
+
+	parts := strings.Split(payload, ":")
+	if len(parts) != 3 {
+		return nil, invalid
+	}
+	userID, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return nil, invalid
+	}
+	update := ChannelPreferenceUpdate{
+		Type:    models.NotificationType(parts[1]),
+		Channel: models.NotificationChannel(parts[2]),
+	}
+	if err := validatePreferenceUpdate(&update); err != nil {
+		return nil, err
+	}
+
+	if err := setPreference(s.db, uint(userID), update.Type, update.Channel, false); err != nil {
+		return nil, err
+	}
+	return &ChannelPreference{Type: update.Type, Channel: update.Channel}, nil
+}
+
+// signUnsubscribe signs an unsubscribe link payload
+func (s *NotificationService) signUnsubscribe(payload string) string {
+	mac := hmac.New(sha256.New, []byte("unsubscribe|"+s.config.JWTSecret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// setPreference creates or updates one preference row
+func setPreference(db *gorm.DB, userID uint, notificationType models.NotificationType, channel models.NotificationChannel, enabled bool) error {
+	preference := models.NotificationPreference{
+		UserID:  userID,
+		Type:    notificationType,
+		Channel: channel,
+		Enabled: enabled,
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&preference).Error
+}
+
+// channelEnabled applies a user's overrides on top of the defaults for a notification type
+func channelEnabled(notificationType models.NotificationType, channel models.NotificationChannel, overrides map[models.NotificationChannel]bool) bool {
+	if notificationType == models.NotificationTypeSecurity {
+		return true
+	}
+	if enabled, ok := overrides[channel]; ok {
+		return enabled
+	}
+	return defaultChannelPreferences[notificationType][channel]
+}
+
+// validatePreferenceUpdate rejects unknown types and channels and attempts to silence security notifications
+func validatePreferenceUpdate(update *ChannelPreferenceUpdate) error {
+	if update.Type == models.NotificationTypeSecurity {
+		if update.Enabled != nil && *update.Enabled {
+			return nil
+		}
+		return errors.New("security notifications cannot be turned off")
+	}
+	if _, ok := defaultChannelPreferences[update.Type]; !ok {
+		return fmt.Errorf("unknown notification type: %s", update.Type)
+	}
+	for _, channel := range notificationChannels {
+		if update.Channel == channel {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown notification channel: %s", update.Channel)
+}
+
+// validateQuietHours checks that quiet hours are two different HH:MM times
+func validateQuietHours(start, end string) error {
+	if _, err := time.Parse("15:04", start); err != nil {
+		return errors.New("quiet hours start must be a time like 22:00")
+	}
+	if _, err := time.Parse("15:04", end); err != nil {
+		return errors.New("quiet hours end must be a time like 07:00")
+	}
+	if start == end {
+		return errors.New("quiet hours start and end must be different")
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"kenyan-food-delivery/internal/auth"
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateOrderRequest represents a checkout request
+type CreateOrderRequest struct {
+	RestaurantID        uint                     `json:"restaurant_id" binding:"required"`
+	AddressID           uint                     `json:"address_id" binding:"required"`
+	Items               []CreateOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+	PaymentMethod       models.PaymentMethod     `json:"payment_method" binding:"required,oneof=mpesa card cash bank_transfer"`
+	SpecialInstructions string                   `json:"special_instructions"`
+	PromoCode           string                   `json:"promo_code"`
+}
+
+// CreateOrderItemRequest represents a single line in a checkout request
+type CreateOrderItemRequest struct {
+	MenuItemID     uint   `json:"menu_item_id" binding:"required"`
+	Quantity       int    `json:"quantity" binding:"required,min=1"`
+	SpecialRequest string `json:"special_request"`
+}
+
+// CreateOrder validates a checkout request, prices it and creates the order.
+// Promo codes are redeemed inside the same transaction as the order insert.
+// Restaurants that are closed, paused or at capacity refuse new orders.
+func (s *OrderService) CreateOrder(userID uint, req *CreateOrderRequest) (*models.Order, error) {
+	var restaurant models.Restaurant
+	if err := s.db.Where("id = ? AND status = ?", req.RestaurantID, models.RestaurantStatusApproved).
+		First(&restaurant).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("restaurant not found")
+		}
+		return nil, err
+	}
+
+	load, err := restaurantLoad(s.db, &restaurant)
+	if err != nil {
+		return nil, err
+	}
+	if err := availabilityError(load.Availability); err != nil {
+		return nil, err
+	}
+
+	var address models.Address
+	if err := s.db.Where("id = ? AND user_id = ?", req.AddressID, userID).First(&address).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("address not found")
+		}
+		return nil, err
+	}
+
+	menuItemIDs := make([]uint, 0, len(req.Items))
+	for _, item := range req.Items {
+		menuItemIDs = append(menuItemIDs, item.MenuItemID)
+	}
+
+	var menuItems []models.MenuItem
+	if err := s.db.Where("id IN ? AND restaurant_id = ?", menuItemIDs, restaurant.ID).
+		Find(&menuItems).Error; err != nil {
+		return nil, err
+	}
+
+	menuItemsByID := make(map[uint]models.MenuItem, len(menuItems))
+	for _, item := range menuItems {
+		menuItemsByID[item.ID] = item
+	}
+
+	var subTotal float64
+	orderItems := make([]models.OrderItem, 0, len(req.Items))
+	for _, line := range req.Items {
+		menuItem, ok := menuItemsByID[line.MenuItemID]
+		if !ok {
+			return nil, fmt.Errorf("menu item %d not found", line.MenuItemID)
+		}
+		if menuItem.Status != models.MenuItemStatusAvailable {
+			return nil, fmt.Errorf("%s is not available", menuItem.Name)
+		}
+
+		unitPrice := menuItem.Price
+		if menuItem.DiscountPrice != nil {
+			unitPrice = *menuItem.DiscountPrice
+		}
+
+		lineTotal := roundAmount(unitPrice * float64(line.Quantity))
+		subTotal += lineTotal
+		orderItems = append(orderItems, models.OrderItem{
+			MenuItemID:     menuItem.ID,
+			Quantity:       line.Quantity,
+			UnitPrice:      unitPrice,
+			TotalPrice:     lineTotal,
+			SpecialRequest: line.SpecialRequest,
+		})
+	}
+	subTotal = roundAmount(subTotal)
+
+	if subTotal < restaurant.MinOrderAmount {
+		return nil, fmt.Errorf("minimum order amount is KES %.2f", restaurant.MinOrderAmount)
+	}
+
+	deliveryFee := restaurant.DeliveryFee
+	if restaurant.FreeDelivery {
+		deliveryFee = 0
+	} else if deliveryFee == 0 {
+		deliveryFee = s.config.DefaultDeliveryFee
+	}
+
+	orderNumber, err := generateOrderNumber()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	acceptBy := now.Add(time.Duration(s.config.OrderAcceptWindow) * time.Second)
+
+	// Provisional until the kitchen accepts with its own prep time; busy mode lengthens it
+	prepTime := defaultPrepMinutes + load.Availability.ExtraPrepMinutes
+	estimatedDelivery := now.Add(time.Duration(prepTime+restaurant.DeliveryTime) * time.Minute)
+
+	order := &models.Order{
+		UserID:                userID,
+		RestaurantID:          restaurant.ID,
+		AddressID:             address.ID,
+		OrderNumber:           orderNumber,
+		Status:                models.OrderStatusPending,
+		SubTotal:              subTotal,
+		DeliveryFee:           deliveryFee,
+		TotalAmount:           roundAmount(subTotal + deliveryFee),
+		PaymentStatus:         string(models.PaymentStatusPending),
+		PaymentMethod:         string(req.PaymentMethod),
+		SpecialInstructions:   req.SpecialInstructions,
+		PrepTime:              prepTime,
+		DeliveryTime:          restaurant.DeliveryTime,
+		EstimatedDeliveryTime: &estimatedDelivery,
+		AcceptBy:              &acceptBy,
+		OrderItems:            orderItems,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Count against capacity one order at a time so a rush cannot overshoot it
+		if restaurant.OrderCapacity > 0 {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&restaurant, restaurant.ID).Error; err != nil {
+				return err
+			}
+			load, err := restaurantLoad(tx, &restaurant)
+			if err != nil {
+				return err
+			}
+			if err := availabilityError(load.Availability); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+
+		if strings.TrimSpace(req.PromoCode) != "" {
+			redemption, err := s.promo.RedeemPromoCode(tx, req.PromoCode, order)
+			if err != nil {
+				return err
+			}
+
+			order.DiscountAmount = redemption.DiscountAmount
+			order.TotalAmount = roundAmount(order.SubTotal + order.DeliveryFee + order.ServiceFee + order.Tax - order.DiscountAmount)
+			if err := tx.Model(order).Updates(map[string]interface{}{
+				"discount_amount": order.DiscountAmount,
+				"total_amount":    order.TotalAmount,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&restaurant).UpdateColumn("total_orders", gorm.Expr("total_orders + ?", 1)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifications.NotifyOrderPlaced(order)
+	s.webhooks.PublishOrderCreated(order)
+	s.publishOrderEvent(OrderEventCreated, order.RestaurantID, order.ID)
+	return order, nil
+}
+
+// generateOrderNumber creates a human-readable unique order number
+func generateOrderNumber() (string, error) {
+	suffix, err := auth.GenerateRandomToken(4)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("KFD-%s-%s", time.Now().Format("20060102"), strings.ToUpper(suffix)), nil
+}
+
+// roundAmount rounds a KES amount to two decimal places
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -10,27 +10,37 @@ import (
 type OrderService struct {
 	db     *gorm.DB
 	config *config.Config
+	promo         *PromoService
+	notifications *NotificationService
+	webhooks      *WebhookService
 }
 
 // NewOrderService creates a new order service
 func NewOrderService(db *gorm.DB, cfg *config.Config) *OrderService {
 	return &OrderService{
-		db:     db,
-		config: cfg,
+		db:            db,
+		config:        cfg,
+		promo:         NewPromoService(db, cfg),
+		notifications: NewNotificationService(db, cfg),
+		webhooks:      NewWebhookService(db, cfg),
 	}
 }
 
 // PaymentService handles payment-related operations
 type PaymentService struct {
-	db     *gorm.DB
-	config *config.Config
+	db            *gorm.DB
+	config        *config.Config
+	notifications *NotificationService
+	webhooks      *WebhookService
 }
 
 // NewPaymentService creates a new payment service
 func NewPaymentService(db *gorm.DB, cfg *config.Config) *PaymentService {
 	return &PaymentService{
-		db:     db,
-		config: cfg,
+		db:            db,
+		config:        cfg,
+		notifications: NewNotificationService(db, cfg),
+		webhooks:      NewWebhookService(db, cfg),
 	}
 }
 
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// expiredOrderBatchSize limits how many unaccepted orders one pass rejects
+const expiredOrderBatchSize = 100
+
+// activeOrderStatuses are the orders still in a restaurant's kitchen queue
+var activeOrderStatuses = []models.OrderStatus{
+	models.OrderStatusPending,
+	models.OrderStatusConfirmed,
+	models.OrderStatusPreparing,
+	models.OrderStatusReady,
+}
+
+// OrderQueueQuery selects a page of a restaurant's orders
+type OrderQueueQuery struct {
+	View  string `form:"view" binding:"omitempty,oneof=active history"` // active (default) or history
+	Page  int    `form:"page"`
+	Limit int    `form:"limit"`
+}
+
+// AcceptOrderRequest accepts a pending order with the kitchen's preparation estimate
+type AcceptOrderRequest struct {
+	PrepTime int `json:"prep_time" binding:"required,min=1,max=240"` // in minutes
+}
+
+// RejectOrderRequest declines a pending order
+type RejectOrderRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// GetOrderQueue lists a restaurant's active orders oldest first, so the next to act on is
+// at the top, or its finished and cancelled orders newest first
+func (s *OrderService) GetOrderQueue(restaurantID uint, query *OrderQueueQuery) ([]models.Order, int64, error) {
+	db := s.db.Model(&models.Order{}).Where("restaurant_id = ?", restaurantID)
+	if query.View == "history" {
+		db = db.Where("status NOT IN ?", activeOrderStatuses).Order("created_at DESC")
+	} else {
+		db = db.Where("status IN ?", activeOrderStatuses).Order("created_at ASC")
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var orders []models.Order
+	if err := preloadQueueItems(db).Offset((query.Page - 1) * query.Limit).Limit(query.Limit).
+		Find(&orders).Error; err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+// AcceptOrder confirms a pending order within its accept window and sets its preparation
+// time, from which the customer's estimated delivery time is worked out
+func (s *OrderService) AcceptOrder(actorID, orderID uint, req *AcceptOrderRequest) (*models.Order, error) {
+	return s.changeOrderStatus(actorID, orderID, models.OrderStatusConfirmed, "",
+		func(tx *gorm.DB, order *models.Order, updates map[string]interface{}) error {
+			if order.Status != models.OrderStatusPending {
+				return errors.New("only pending orders can be accepted")
+			}
+
+			var restaurant models.Restaurant
+			if err := tx.Select("id", "delivery_time").First(&restaurant, order.RestaurantID).Error; err != nil {
+				return err
+			}
+			estimate := time.Now().Add(time.Duration(req.PrepTime+restaurant.DeliveryTime) * time.Minute)
+			updates["prep_time"] = req.PrepTime
+			updates["delivery_time"] = restaurant.DeliveryTime
+			updates["estimated_delivery_time"] = estimate
+			return nil
+		})
+}
+
+// RejectOrder declines a pending order, queues its refund and releases its promo code use
+func (s *OrderService) RejectOrder(actorID, orderID uint, req *RejectOrderRequest) (*models.Order, error) {
+	return s.changeOrderStatus(actorID, orderID, models.OrderStatusCancelled, req.Reason, requirePending)
+}
+
+// MarkOrderReady marks an accepted order ready for pickup and dispatches it to drivers
+func (s *OrderService) MarkOrderReady(actorID, orderID uint) (*models.Order, error) {
+	return s.changeOrderStatus(actorID, orderID, models.OrderStatusReady, "", nil)
+}
+
+// RejectExpiredOrders rejects one batch of orders left pending past their accept window,
+// queueing their refunds and releasing their promo code uses, and returns how many it rejected
+func (s *OrderService) RejectExpiredOrders() (int64, error) {
+	var orderIDs []uint
+	if err := s.db.Model(&models.Order{}).
+		Where("status = ? AND accept_by < ?", models.OrderStatusPending, time.Now()).
+		Order("accept_by ASC").Limit(expiredOrderBatchSize).Pluck("id", &orderIDs).Error; err != nil {
+		return 0, err
+	}
+
+	var rejected int64
+	for _, orderID := range orderIDs {
+		if _, err := s.changeOrderStatus(0, orderID, models.OrderStatusCancelled,
+			"The restaurant did not respond in time", requirePending); err != nil {
+			// Accepted or cancelled since it was listed
+			log.Printf("Failed to reject expired order %d: %v", orderID, err)
+			continue
+		}
+		rejected++
+	}
+	return rejected, nil
+}
+
+// RunAcceptWindow rejects orders not accepted in time every interval.
+// It never returns, so callers start it in its own goroutine.
+func (s *OrderService) RunAcceptWindow(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		rejected, err := s.RejectExpiredOrders()
+		if err != nil {
+			log.Printf("Failed to reject expired orders: %v", err)
+			continue
+		}
+		if rejected > 0 {
+			log.Printf("Rejected %d orders not accepted in time", rejected)
+		}
+	}
+}
+
+// requirePending stops a rejection racing with the order being accepted
+func requirePending(tx *gorm.DB, order *models.Order, updates map[string]interface{}) error {
+	if order.Status != models.OrderStatusPending {
+		return errors.New("only pending orders can be rejected")
+	}
+	return nil
+}
+
+// preloadQueueItems loads an order's lines with the names the kitchen needs, including
+// items deleted from the menu since the order was placed
+func preloadQueueItems(db *gorm.DB) *gorm.DB {
+	return db.Preload("OrderItems.MenuItem", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Select("id", "name", "image")
+	})
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"kenyan-food-delivery/internal/auth"
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// restaurantStatusTransitions lists the order status changes a restaurant may make
+var restaurantStatusTransitions = map[models.OrderStatus][]models.OrderStatus{
+	models.OrderStatusPending:   {models.OrderStatusConfirmed, models.OrderStatusCancelled},
+	models.OrderStatusConfirmed: {models.OrderStatusPreparing, models.OrderStatusReady, models.OrderStatusCancelled},
+	models.OrderStatusPreparing: {models.OrderStatusReady},
+}
+
+// UpdateOrderStatusRequest represents a restaurant-side order status change
+type UpdateOrderStatusRequest struct {
+	Status models.OrderStatus `json:"status" binding:"required,oneof=confirmed preparing ready cancelled"`
+	Note   string             `json:"note"` // Required when cancelling
+}
+
+// UpdateOrderStatus moves an order along the restaurant workflow and records who did it
+func (s *OrderService) UpdateOrderStatus(actorID, orderID uint, req *UpdateOrderStatusRequest) (*models.Order, error) {
+	if req.Status == models.OrderStatusCancelled && req.Note == "" {
+		return nil, errors.New("a reason is required to cancel an order")
+	}
+
+	return s.changeOrderStatus(actorID, orderID, req.Status, req.Note, nil)
+}
+
+// orderUpdateFunc checks an order locked for a status change and may add columns to the update
+type orderUpdateFunc func(tx *gorm.DB, order *models.Order, updates map[string]interface{}) error
+
+// changeOrderStatus makes a restaurant-side status change under a row lock and records it.
+// Cancelling queues a refund of any completed payment and releases the promo code use, and
+// marking an order ready dispatches it.
+// An actorID of 0 is the system, e.g. when an order is not accepted in time.
+func (s *OrderService) changeOrderStatus(actorID, orderID uint, to models.OrderStatus, note string, check orderUpdateFunc) (*models.Order, error) {
+	var order models.Order
+	var from models.OrderStatus
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.New("order not found")
+			}
+			return err
+		}
+
+		if !canTransition(order.Status, to) {
+			return fmt.Errorf("cannot change order from %s to %s", order.Status, to)
+		}
+
+		now := time.Now()
+		from = order.Status
+		updates := map[string]interface{}{"status": to}
+		switch to {
+		case models.OrderStatusConfirmed:
+			if order.AcceptBy != nil && now.After(*order.AcceptBy) {
+				return errors.New("the time to accept this order has passed")
+			}
+			updates["accepted_at"] = now
+		case models.OrderStatusCancelled:
+			updates["cancelled_at"] = now
+			updates["cancel_reason"] = note
+			if actorID != 0 {
+				updates["cancelled_by"] = actorID
+			}
+		case models.OrderStatusReady:
+			updates["ready_at"] = now
+		}
+		if check != nil {
+			if err := check(tx, &order, updates); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&order).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&order, order.ID).Error; err != nil {
+			return err
+		}
+
+		switch to {
+		case models.OrderStatusCancelled:
+			if err := refundOrderPayments(tx, &order, note); err != nil {
+				return err
+			}
+			if err := s.promo.ReleaseRedemption(tx, order.ID); err != nil {
+				return err
+			}
+		case models.OrderStatusReady:
+			if err := dispatchOrder(tx, &order); err != nil {
+				return err
+			}
+		}
+
+		return recordOrderAudit(tx, &order, actorID, models.OrderAuditStatusChanged, from, to, note)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifications.NotifyOrderStatus(&order)
+	s.webhooks.PublishOrderStatusChanged(&order, from)
+	s.publishOrderEvent(OrderEventUpdated, order.RestaurantID, order.ID)
+	return &order, nil
+}
+
+// refundOrderPayments marks a cancelled order's completed payments as owed back in full.
+// No money moves here: finance pays each refund through the provider and confirms it with
+// PaymentService.CompleteRefund, which is when the customer is told.
+func refundOrderPayments(tx *gorm.DB, order *models.Order, reason string) error {
+	result := tx.Model(&models.Payment{}).
+		Where("order_id = ? AND status = ?", order.ID, models.PaymentStatusCompleted).
+		Updates(map[string]interface{}{
+			"status":        models.PaymentStatusRefundPending,
+			"refund_amount": gorm.Expr("amount"),
+			"refund_reason": reason,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	order.PaymentStatus = string(models.PaymentStatusRefundPending)
+	return tx.Model(order).Update("payment_status", order.PaymentStatus).Error
+}
+
+// dispatchOrder opens a delivery for an order that is ready, for the next driver to accept
+func dispatchOrder(tx *gorm.DB, order *models.Order) error {
+	var existing int64
+	if err := tx.Model(&models.Delivery{}).Where("order_id = ?", order.ID).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	code, err := auth.GenerateRandomToken(5)
+	if err != nil {
+		return err
+	}
+	delivery := models.Delivery{
+		OrderID:       order.ID,
+		Status:        models.DeliveryStatusPending,
+		EstimatedTime: order.EstimatedDeliveryTime,
+		DeliveryFee:   order.DeliveryFee,
+		DeliveryNotes: order.SpecialInstructions,
+		TrackingCode:  "TRK-" + strings.ToUpper(code),
+	}
+	return tx.Create(&delivery).Error
+}
+
+// GetOrderAuditLog lists the changes made to an order, oldest first
+func (s *OrderService) GetOrderAuditLog(orderID uint) ([]models.OrderAuditLog, error) {
+	var entries []models.OrderAuditLog
+	if err := s.db.Preload("Actor", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "first_name", "last_name")
+	}).Where("order_id = ?", orderID).Order("created_at ASC, id ASC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// canTransition reports whether a restaurant may move an order between the statuses
+func canTransition(from, to models.OrderStatus) bool {
+	for _, allowed := range restaurantStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// recordOrderAudit writes an audit entry naming the actor's relationship to the restaurant:
+// owner, their staff role and membership, their platform role, or system for automatic changes
+func recordOrderAudit(tx *gorm.DB, order *models.Order, actorID uint, action string, from, to models.OrderStatus, note string) error {
+	entry := models.OrderAuditLog{
+		OrderID:      order.ID,
+		RestaurantID: order.RestaurantID,
+		Action:       action,
+		FromStatus:   from,
+		ToStatus:     to,
+		Note:         note,
+	}
+
+	if actorID == 0 {
+		entry.ActorRole = "system"
+		return tx.Create(&entry).Error
+	}
+	entry.ActorID = &actorID
+
+	var restaurant models.Restaurant
+	if err := tx.Select("id", "owner_id").First(&restaurant, order.RestaurantID).Error; err != nil {
+		return err
+	}
+
+	var staff models.RestaurantStaff
+	staffErr := tx.Where("restaurant_id = ? AND user_id = ? AND status = ?", order.RestaurantID, actorID, models.StaffStatusActive).
+		First(&staff).Error
+
+	switch {
+	case restaurant.OwnerID == actorID:
+		entry.ActorRole = "owner"
+	case staffErr == nil:
+		entry.ActorRole = string(staff.Role)
+		entry.StaffID = &staff.ID
+	case staffErr != gorm.ErrRecordNotFound:
+		return staffErr
+	default:
+		var actor models.User
+		if err := tx.Select("id", "role").First(&actor, actorID).Error; err != nil {
+			return err
+		}
+		entry.ActorRole = string(actor.Role)
+	}
+
+	return tx.Create(&entry).Error
+}
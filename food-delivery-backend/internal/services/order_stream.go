@@ -0,0 +1,84 @@
+package services
+
+import (
+	"log"
+	"sync"
+
+	"kenyan-food-delivery/internal/models"
+)
+
+// Order stream event types
+const (
+	OrderEventCreated = "order.created"
+	OrderEventUpdated = "order.updated"
+)
+
+// orderStreamBuffer is how far a kitchen screen may fall behind before its events are dropped
+const orderStreamBuffer = 32
+
+// OrderEvent is sent to a restaurant's kitchen screens when an order arrives or changes
+type OrderEvent struct {
+	Type  string        `json:"type"`
+	Order *models.Order `json:"order"`
+}
+
+// orderStreamHub fans order events out to the kitchen screens connected to this instance
+type orderStreamHub struct {
+	mu          sync.Mutex
+	subscribers map[uint]map[chan OrderEvent]struct{}
+}
+
+var orderStreams = &orderStreamHub{subscribers: make(map[uint]map[chan OrderEvent]struct{})}
+
+// SubscribeOrders streams a restaurant's order events until the returned cancel is called.
+// Events only reach screens connected to the instance that handled the change, so clients
+// should reload the queue when they reconnect.
+func (s *OrderService) SubscribeOrders(restaurantID uint) (<-chan OrderEvent, func()) {
+	events := make(chan OrderEvent, orderStreamBuffer)
+
+	orderStreams.mu.Lock()
+	if orderStreams.subscribers[restaurantID] == nil {
+		orderStreams.subscribers[restaurantID] = make(map[chan OrderEvent]struct{})
+	}
+	orderStreams.subscribers[restaurantID][events] = struct{}{}
+	orderStreams.mu.Unlock()
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			orderStreams.mu.Lock()
+			delete(orderStreams.subscribers[restaurantID], events)
+			if len(orderStreams.subscribers[restaurantID]) == 0 {
+				delete(orderStreams.subscribers, restaurantID)
+			}
+			orderStreams.mu.Unlock()
+		})
+	}
+}
+
+// publishOrderEvent sends an order with its items to the restaurant's connected screens
+func (s *OrderService) publishOrderEvent(eventType string, restaurantID, orderID uint) {
+	orderStreams.mu.Lock()
+	listening := len(orderStreams.subscribers[restaurantID]) > 0
+	orderStreams.mu.Unlock()
+	if !listening {
+		return
+	}
+
+	var order models.Order
+	if err := preloadQueueItems(s.db).First(&order, orderID).Error; err != nil {
+		log.Printf("Failed to load order %d for the kitchen stream: %v", orderID, err)
+		return
+	}
+	event := OrderEvent{Type: eventType, Order: &order}
+
+	orderStreams.mu.Lock()
+	defer orderStreams.mu.Unlock()
+	for events := range orderStreams.subscribers[restaurantID] {
+		select {
+		case events <- event:
+		default:
+			log.Printf("Dropped %s event for order %d: kitchen screen is not keeping up", eventType, orderID)
+		}
+	}
+}
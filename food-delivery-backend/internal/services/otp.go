@@ -0,0 +1,177 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+	"kenyan-food-delivery/internal/templates"
+	"kenyan-food-delivery/pkg/location"
+
+	"gorm.io/gorm"
+)
+
+// OTP issuing rules
+const (
+	otpLength         = 6
+	otpLifetime       = 5 * time.Minute
+	otpMaxAttempts    = 5
+	otpResendCooldown = time.Minute
+	otpMaxPerHour     = 5
+)
+
+// OTPService issues and verifies one-time codes delivered by SMS
+type OTPService struct {
+	db     *gorm.DB
+	config *config.Config
+	sms    *SMSService
+}
+
+// NewOTPService creates a new OTP service
+func NewOTPService(db *gorm.DB, cfg *config.Config, sms *SMSService) *OTPService {
+	return &OTPService{
+		db:     db,
+		config: cfg,
+		sms:    sms,
+	}
+}
+
+// SendCode issues a new code for a phone number and purpose, replacing any outstanding one
+func (s *OTPService) SendCode(phoneNumber string, purpose models.OTPPurpose, userID *uint) error {
+	phoneNumber, err := location.NormalizePhoneNumber(phoneNumber)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var latest models.OTPCode
+	err = s.db.Where("phone_number = ? AND purpose = ?", phoneNumber, purpose).
+		Order("created_at DESC").First(&latest).Error
+	if err == nil {
+		if wait := otpResendCooldown - now.Sub(latest.CreatedAt); wait > 0 {
+			return fmt.Errorf("please wait %d seconds before requesting another code", int(wait.Seconds())+1)
+		}
+	} else if err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	var recent int64
+	if err := s.db.Model(&models.OTPCode{}).
+		Where("phone_number = ? AND purpose = ? AND created_at > ?", phoneNumber, purpose, now.Add(-time.Hour)).
+		Count(&recent).Error; err != nil {
+		return err
+	}
+	if recent >= otpMaxPerHour {
+		return errors.New("too many codes requested, please try again later")
+	}
+
+	code, err := generateOTP()
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OTPCode{}).
+			Where("phone_number = ? AND purpose = ? AND consumed_at IS NULL", phoneNumber, purpose).
+			Update("consumed_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.OTPCode{
+			PhoneNumber: phoneNumber,
+			Purpose:     purpose,
+			UserID:      userID,
+			CodeHash:    s.hashCode(phoneNumber, purpose, code),
+			ExpiresAt:   now.Add(otpLifetime),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	language := templates.DefaultLocale
+	if userID != nil {
+		var user models.User
+		if err := s.db.Select("preferred_language").First(&user, *userID).Error; err == nil {
+			language = user.PreferredLanguage
+		}
+	}
+	message, err := templates.RenderSMS(language, "otp", map[string]interface{}{
+		"Code":    code,
+		"Minutes": int(otpLifetime.Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+	return s.sms.Send(phoneNumber, message, SMSCategoryOTP)
+}
+
+// VerifyCode checks a code and consumes it on success. Each wrong guess counts against the code.
+func (s *OTPService) VerifyCode(phoneNumber string, purpose models.OTPPurpose, code string) error {
+	phoneNumber, err := location.NormalizePhoneNumber(phoneNumber)
+	if err != nil {
+		return err
+	}
+
+	var otp models.OTPCode
+	if err := s.db.Where("phone_number = ? AND purpose = ? AND consumed_at IS NULL AND expires_at > ?",
+		phoneNumber, purpose, time.Now()).
+		Order("created_at DESC").First(&otp).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("code is invalid or has expired")
+		}
+		return err
+	}
+
+	// Count the attempt before comparing so concurrent guesses cannot exceed the limit
+	result := s.db.Model(&models.OTPCode{}).
+		Where("id = ? AND attempts < ?", otp.ID, otpMaxAttempts).
+		UpdateColumn("attempts", gorm.Expr("attempts + ?", 1))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("too many incorrect attempts, please request a new code")
+	}
+
+	expected := s.hashCode(phoneNumber, purpose, code)
+	if !hmac.Equal([]byte(expected), []byte(otp.CodeHash)) {
+		return errors.New("incorrect code")
+	}
+
+	result = s.db.Model(&models.OTPCode{}).
+		Where("id = ? AND consumed_at IS NULL", otp.ID).
+		Update("consumed_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("code is invalid or has expired")
+	}
+
+	return nil
+}
+
+// hashCode keys the hash with the server secret so stored hashes cannot be brute-forced offline
+func (s *OTPService) hashCode(phoneNumber string, purpose models.OTPPurpose, code string) string {
+	mac := hmac.New(sha256.New, []byte(s.config.JWTSecret))
+	mac.Write([]byte(phoneNumber + "|" + string(purpose) + "|" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// generateOTP returns a uniformly random numeric code
+func generateOTP() (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(otpLength), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", otpLength, n), nil
+}
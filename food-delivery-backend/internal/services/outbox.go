@@ -0,0 +1,317 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+	"kenyan-food-delivery/internal/templates"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Outbox delivery rules
+const (
+	outboxBatchSize     = 20
+	outboxBaseBackoff   = 30 * time.Second // Doubled after each failed attempt
+	outboxMaxBackoff    = 2 * time.Hour
+	outboxClaimTimeout  = 10 * time.Minute // A message left sending this long was abandoned by a crashed worker
+	outboxSentRetention = 30 * 24 * time.Hour
+)
+
+// outboxWake lets the worker send a new message straight away instead of at its next poll
+var outboxWake = make(chan struct{}, 1)
+
+// OutboxService queues email and SMS for a background worker, so requests don't wait on
+// providers and failed sends are retried instead of lost
+type OutboxService struct {
+	db        *gorm.DB
+	config    *config.Config
+	sms       *SMSService
+	transport EmailTransport
+}
+
+// NewOutboxService creates a new outbox service
+func NewOutboxService(db *gorm.DB, cfg *config.Config) *OutboxService {
+	return &OutboxService{
+		db:        db,
+		config:    cfg,
+		sms:       NewSMSService(db, cfg),
+		transport: sharedEmailTransport(cfg),
+	}
+}
+
+// EmailTransport returns the configured transport, e.g. to read captured emails in development
+func (s *OutboxService) EmailTransport() EmailTransport {
+	return s.transport
+}
+
+// EnqueueEmail queues a rendered email for sending. sensitive marks emails carrying a link
+// or code that expires, which are never kept or resent once they fail.
+func (s *OutboxService) EnqueueEmail(to string, email *templates.Email, headers map[string]string, sensitive bool) error {
+	message := &models.OutboxMessage{
+		Channel:   models.OutboxChannelEmail,
+		Recipient: to,
+		Subject:   email.Subject,
+		TextBody:  email.Text,
+		HTMLBody:  email.HTML,
+		Sensitive: sensitive,
+	}
+	if len(headers) > 0 {
+		encoded, err := json.Marshal(headers)
+		if err != nil {
+			return err
+		}
+		message.Headers = string(encoded)
+	}
+	return s.enqueue(message)
+}
+
+// EnqueueSMS queues a text message for sending; sensitive is as for EnqueueEmail
+func (s *OutboxService) EnqueueSMS(to, text, category string, sensitive bool) error {
+	return s.enqueue(&models.OutboxMessage{
+		Channel:   models.OutboxChannelSMS,
+		Recipient: to,
+		Category:  category,
+		TextBody:  text,
+		Sensitive: sensitive,
+	})
+}
+
+// enqueue stores a message as due now and wakes the worker
+func (s *OutboxService) enqueue(message *models.OutboxMessage) error {
+	message.Status = models.OutboxStatusPending
+	message.NextAttemptAt = time.Now()
+	if err := s.db.Create(message).Error; err != nil {
+		return err
+	}
+
+	wakeOutbox()
+	return nil
+}
+
+// wakeOutbox tells the worker there is a message to send
+func wakeOutbox() {
+	select {
+	case outboxWake <- struct{}{}:
+	default: // The worker is already due to run
+	}
+}
+
+// RunWorker sends due messages whenever one is queued, and every interval for retries; it never returns
+func (s *OutboxService) RunWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := s.ProcessDue()
+			if err != nil {
+				log.Printf("Failed to process outbox: %v", err)
+			}
+			// A full batch means more messages may be waiting
+			if err != nil || processed < outboxBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-outboxWake:
+		}
+	}
+}
+
+// ProcessDue sends one batch of due messages, returning how many it attempted
+func (s *OutboxService) ProcessDue() (int, error) {
+	messages, err := s.claim()
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range messages {
+		s.recordAttempt(&messages[i], s.deliver(&messages[i]))
+	}
+	return len(messages), nil
+}
+
+// claim marks a batch of due messages as sending. Rows locked by another worker are
+// skipped, so several instances can share the outbox.
+func (s *OutboxService) claim() ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND updated_at < ?)",
+				models.OutboxStatusPending, now, models.OutboxStatusSending, now.Add(-outboxClaimTimeout)).
+			Order("next_attempt_at").
+			Limit(outboxBatchSize).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+		return tx.Model(&models.OutboxMessage{}).Where("id IN ?", ids).
+			Update("status", models.OutboxStatusSending).Error
+	})
+	return messages, err
+}
+
+// deliver sends a message through its channel's provider
+func (s *OutboxService) deliver(message *models.OutboxMessage) error {
+	switch message.Channel {
+	case models.OutboxChannelEmail:
+		var headers map[string]string
+		if message.Headers != "" {
+			if err := json.Unmarshal([]byte(message.Headers), &headers); err != nil {
+				return err
+			}
+		}
+		return deliverEmail(s.config, s.transport, message.Recipient, &templates.Email{
+			Subject: message.Subject,
+			Text:    message.TextBody,
+			HTML:    message.HTMLBody,
+		}, headers)
+	case models.OutboxChannelSMS:
+		return s.sms.Send(message.Recipient, message.TextBody, message.Category)
+	default:
+		return errors.New("unknown outbox channel: " + string(message.Channel))
+	}
+}
+
+// recordAttempt marks a message sent, schedules a retry with exponential backoff, or
+// gives up once the maximum attempts are used
+func (s *OutboxService) recordAttempt(message *models.OutboxMessage, sendErr error) {
+	now := time.Now()
+	attempts := message.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts}
+
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.OutboxStatusSent
+		updates["sent_at"] = now
+		updates["last_error"] = ""
+		// The content is no longer needed and may contain sign-in links
+		updates["headers"] = ""
+		updates["text_body"] = ""
+		updates["html_body"] = ""
+	case attempts >= s.config.OutboxMaxAttempts:
+		updates["status"] = models.OutboxStatusFailed
+		updates["last_error"] = sendErr.Error()
+		if message.Sensitive {
+			// It cannot be resent, so its link or code is only a liability
+			updates["headers"] = ""
+			updates["text_body"] = ""
+			updates["html_body"] = ""
+		}
+		log.Printf("Giving up on %s %d to %s after %d attempts: %v",
+			message.Channel, message.ID, message.Recipient, attempts, sendErr)
+	default:
+		updates["status"] = models.OutboxStatusPending
+		updates["next_attempt_at"] = now.Add(retryBackoff(attempts, outboxBaseBackoff, outboxMaxBackoff))
+		updates["last_error"] = sendErr.Error()
+		log.Printf("Failed to send %s %d to %s (attempt %d): %v",
+			message.Channel, message.ID, message.Recipient, attempts, sendErr)
+	}
+
+	if err := s.db.Model(message).Updates(updates).Error; err != nil {
+		log.Printf("Failed to record outbox attempt for message %d: %v", message.ID, err)
+	}
+}
+
+// retryBackoff is how long to wait before the next attempt after the given number of
+// failures: base, doubling each time up to max
+func retryBackoff(attempts int, base, max time.Duration) time.Duration {
+	backoff := base
+	for i := 1; i < attempts && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	return backoff
+}
+
+// List returns outbox messages for admins, newest first; empty filters match everything
+func (s *OutboxService) List(status models.OutboxStatus, channel models.OutboxChannel, page, limit int) ([]models.OutboxMessage, int64, error) {
+	var messages []models.OutboxMessage
+	var total int64
+
+	query := s.db.Model(&models.OutboxMessage{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Offset(offset).Limit(limit).Order("id DESC").Find(&messages).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return messages, total, nil
+}
+
+// Resend queues a failed message again with a fresh set of attempts. Sensitive messages
+// are refused, as their link or code has likely expired; the user should request a new one.
+func (s *OutboxService) Resend(messageID uint) (*models.OutboxMessage, error) {
+	var message models.OutboxMessage
+	if err := s.db.First(&message, messageID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("message not found")
+		}
+		return nil, err
+	}
+	if message.Status != models.OutboxStatusFailed {
+		return nil, errors.New("only failed messages can be resent")
+	}
+	if message.Sensitive {
+		return nil, errors.New("message contained a link or code that expires and cannot be resent")
+	}
+
+	result := s.db.Model(&message).Where("status = ?", models.OutboxStatusFailed).Updates(map[string]interface{}{
+		"status":          models.OutboxStatusPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New("only failed messages can be resent")
+	}
+
+	wakeOutbox()
+
+	message.Status = models.OutboxStatusPending
+	message.Attempts = 0
+	return &message, nil
+}
+
+// CleanupSent deletes records of messages sent, or given up on, longer ago than the retention period
+func (s *OutboxService) CleanupSent() (int64, error) {
+	cutoff := time.Now().Add(-outboxSentRetention)
+	result := s.db.Where("(status = ? AND sent_at < ?) OR (status = ? AND updated_at < ?)",
+		models.OutboxStatusSent, cutoff, models.OutboxStatusFailed, cutoff).Delete(&models.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
+
+// RunCleanup calls CleanupSent every interval; it never returns
+func (s *OutboxService) RunCleanup(interval time.Duration) {
+	runPeriodically(interval, "sent and failed outbox messages", s.CleanupSent)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CompletePayment records that a provider confirmed a payment, marks its order paid and
+// tells the payer and the restaurant's webhook subscribers. Confirming twice is a no-op.
+// A payment confirmed after its order was cancelled is not applied to the order; it is
+// queued for a refund instead.
+func (s *PaymentService) CompletePayment(paymentID uint, transactionID, receiptNumber string) (*models.Payment, error) {
+	var payment models.Payment
+	completed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id", "order_id").First(&payment, paymentID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.New("payment not found")
+			}
+			return err
+		}
+
+		// Lock the order before the payment, as cancellations do, so a payment cannot
+		// land on an order that is being cancelled
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").First(&order, payment.OrderID).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, paymentID).Error; err != nil {
+			return err
+		}
+
+		switch payment.Status {
+		case models.PaymentStatusCompleted, models.PaymentStatusRefundPending, models.PaymentStatusRefunded:
+			return nil
+		case models.PaymentStatusPending:
+		default:
+			return errors.New("payment is " + string(payment.Status))
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":               models.PaymentStatusCompleted,
+			"transaction_id":       transactionID,
+			"mpesa_receipt_number": receiptNumber,
+			"processed_at":         now,
+		}
+		orderPaymentStatus := "paid"
+		if order.Status == models.OrderStatusCancelled || order.Status == models.OrderStatusRefunded {
+			updates["status"] = models.PaymentStatusRefundPending
+			updates["refund_amount"] = payment.Amount
+			updates["refund_reason"] = "Payment received after the order was cancelled"
+			orderPaymentStatus = string(models.PaymentStatusRefundPending)
+		}
+		if err := tx.Model(&payment).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&payment, paymentID).Error; err != nil {
+			return err
+		}
+
+		completed = payment.Status == models.PaymentStatusCompleted
+		return tx.Model(&models.Order{}).Where("id = ?", payment.OrderID).Update("payment_status", orderPaymentStatus).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if completed {
+		s.notifications.NotifyPaymentStatus(&payment)
+		s.webhooks.PublishPaymentCompleted(&payment)
+	}
+	return &payment, nil
+}
+
+// CompleteRefundRequest records the provider transaction that paid a refund back
+type CompleteRefundRequest struct {
+	Reference string `json:"reference" binding:"required,max=100"` // e.g. the M-Pesa reversal transaction ID
+}
+
+// CompleteRefund records that finance has paid a pending refund back through the provider,
+// marks the order refunded once none of its refunds are outstanding, and tells the payer
+func (s *PaymentService) CompleteRefund(paymentID uint, req *CompleteRefundRequest) (*models.Payment, error) {
+	var payment models.Payment
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, paymentID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.New("payment not found")
+			}
+			return err
+		}
+		if payment.Status != models.PaymentStatusRefundPending {
+			return errors.New("payment has no pending refund")
+		}
+
+		now := time.Now()
+		if err := tx.Model(&payment).Updates(map[string]interface{}{
+			"status":           models.PaymentStatusRefunded,
+			"refund_reference": req.Reference,
+			"refunded_at":      now,
+		}).Error; err != nil {
+			return err
+		}
+		payment.Status = models.PaymentStatusRefunded
+		payment.RefundReference = req.Reference
+		payment.RefundedAt = &now
+
+		var outstanding int64
+		if err := tx.Model(&models.Payment{}).
+			Where("order_id = ? AND status = ?", payment.OrderID, models.PaymentStatusRefundPending).
+			Count(&outstanding).Error; err != nil {
+			return err
+		}
+		if outstanding > 0 {
+			return nil
+		}
+		return tx.Model(&models.Order{}).Where("id = ?", payment.OrderID).
+			Update("payment_status", models.PaymentStatusRefunded).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifications.NotifyPaymentStatus(&payment)
+	return &payment, nil
+}
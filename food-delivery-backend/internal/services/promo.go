@@ -0,0 +1,473 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PromoService handles promo code management and redemption
+type PromoService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewPromoService creates a new promo service
+func NewPromoService(db *gorm.DB, cfg *config.Config) *PromoService {
+	return &PromoService{
+		db:     db,
+		config: cfg,
+	}
+}
+
+// PromoCodeRequest represents promo code creation/update request
+type PromoCodeRequest struct {
+	Code              string                   `json:"code" binding:"required"`
+	Description       string                   `json:"description"`
+	DiscountType      models.PromoDiscountType `json:"discount_type" binding:"required,oneof=percentage fixed"`
+	DiscountValue     float64                  `json:"discount_value" binding:"required,gt=0"`
+	MinOrderAmount    float64                  `json:"min_order_amount" binding:"gte=0"`
+	MaxDiscountAmount float64                  `json:"max_discount_amount" binding:"gte=0"`
+	StartsAt          *time.Time               `json:"starts_at"`
+	ExpiresAt         *time.Time               `json:"expires_at"`
+	UsageLimit        int                      `json:"usage_limit" binding:"gte=0"`
+	PerUserLimit      int                      `json:"per_user_limit" binding:"gte=0"`
+	FirstOrderOnly    bool                     `json:"first_order_only"`
+	IsActive          *bool                    `json:"is_active"`
+	RestaurantIDs     []uint                   `json:"restaurant_ids"`
+	CuisineIDs        []uint                   `json:"cuisine_ids"`
+	CountyIDs         []uint                   `json:"county_ids"`
+}
+
+// ValidatePromoRequest represents a checkout-time promo code check
+type ValidatePromoRequest struct {
+	Code         string  `json:"code" binding:"required"`
+	RestaurantID uint    `json:"restaurant_id" binding:"required"`
+	SubTotal     float64 `json:"sub_total" binding:"required,gt=0"`
+}
+
+// PromoQuote represents the discount a promo code would give
+type PromoQuote struct {
+	Code           string  `json:"code"`
+	Description    string  `json:"description"`
+	DiscountAmount float64 `json:"discount_amount"`
+	SubTotal       float64 `json:"sub_total"`
+}
+
+// CreatePromoCode creates a new promo code
+func (s *PromoService) CreatePromoCode(adminID uint, req *PromoCodeRequest) (*models.PromoCode, error) {
+	if err := validatePromoRequest(req); err != nil {
+		return nil, err
+	}
+
+	code := normalizePromoCode(req.Code)
+	var existing models.PromoCode
+	if err := s.db.Unscoped().Where("code = ?", code).First(&existing).Error; err == nil {
+		return nil, errors.New("promo code already exists")
+	}
+
+	promo := &models.PromoCode{
+		Code:      code,
+		IsActive:  true,
+		CreatedBy: &adminID,
+	}
+	applyPromoRequest(promo, req)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(promo).Error; err != nil {
+			return err
+		}
+		// GORM skips zero values for columns with defaults, so persist an inactive flag explicitly
+		if !promo.IsActive {
+			if err := tx.Model(promo).Update("is_active", false).Error; err != nil {
+				return err
+			}
+		}
+		return s.replaceScopes(tx, promo, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPromoCode(promo.ID)
+}
+
+// UpdatePromoCode updates an existing promo code
+func (s *PromoService) UpdatePromoCode(id uint, req *PromoCodeRequest) (*models.PromoCode, error) {
+	if err := validatePromoRequest(req); err != nil {
+		return nil, err
+	}
+
+	var promo models.PromoCode
+	if err := s.db.First(&promo, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("promo code not found")
+		}
+		return nil, err
+	}
+
+	code := normalizePromoCode(req.Code)
+	if code != promo.Code {
+		var existing models.PromoCode
+		if err := s.db.Unscoped().Where("code = ?", code).First(&existing).Error; err == nil {
+			return nil, errors.New("promo code already exists")
+		}
+		promo.Code = code
+	}
+	applyPromoRequest(&promo, req)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&promo).Error; err != nil {
+			return err
+		}
+		return s.replaceScopes(tx, &promo, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPromoCode(promo.ID)
+}
+
+// GetPromoCode gets a promo code with its scoping
+func (s *PromoService) GetPromoCode(id uint) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	if err := s.db.Preload("Restaurants").Preload("Cuisines").Preload("Counties").
+		First(&promo, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("promo code not found")
+		}
+		return nil, err
+	}
+
+	return &promo, nil
+}
+
+// GetPromoCodes lists promo codes with pagination
+func (s *PromoService) GetPromoCodes(page, limit int, activeOnly bool) ([]models.PromoCode, int64, error) {
+	var promos []models.PromoCode
+	var total int64
+
+	query := s.db.Model(&models.PromoCode{})
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Preload("Restaurants").Preload("Cuisines").Preload("Counties").
+		Offset(offset).Limit(limit).
+		Order("created_at DESC").
+		Find(&promos).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return promos, total, nil
+}
+
+// DeactivatePromoCode stops a promo code from being redeemed
+func (s *PromoService) DeactivatePromoCode(id uint) error {
+	result := s.db.Model(&models.PromoCode{}).Where("id = ?", id).Update("is_active", false)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("promo code not found")
+	}
+
+	return nil
+}
+
+// ValidatePromoCode checks a promo code against a prospective order without redeeming it
+func (s *PromoService) ValidatePromoCode(userID uint, req *ValidatePromoRequest) (*PromoQuote, error) {
+	promo, err := s.findPromoCode(s.db, req.Code, false)
+	if err != nil {
+		return nil, err
+	}
+
+	discount, err := s.evaluate(s.db, promo, userID, req.RestaurantID, req.SubTotal, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PromoQuote{
+		Code:           promo.Code,
+		Description:    promo.Description,
+		DiscountAmount: discount,
+		SubTotal:       req.SubTotal,
+	}, nil
+}
+
+// RedeemPromoCode applies a promo code to an order inside the caller's transaction.
+// The promo code row is locked so concurrent checkouts cannot oversubscribe a limited code.
+func (s *PromoService) RedeemPromoCode(tx *gorm.DB, code string, order *models.Order) (*models.PromoRedemption, error) {
+	promo, err := s.findPromoCode(tx, code, true)
+	if err != nil {
+		return nil, err
+	}
+
+	discount, err := s.evaluate(tx, promo, order.UserID, order.RestaurantID, order.SubTotal, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(promo).UpdateColumn("usage_count", gorm.Expr("usage_count + ?", 1)).Error; err != nil {
+		return nil, err
+	}
+
+	redemption := &models.PromoRedemption{
+		PromoCodeID:    promo.ID,
+		UserID:         order.UserID,
+		OrderID:        order.ID,
+		DiscountAmount: discount,
+	}
+	if err := tx.Create(redemption).Error; err != nil {
+		return nil, err
+	}
+
+	return redemption, nil
+}
+
+// ReleaseRedemption returns a redeemed use to the pool, e.g. when an order is cancelled
+func (s *PromoService) ReleaseRedemption(tx *gorm.DB, orderID uint) error {
+	var redemption models.PromoRedemption
+	if err := tx.Where("order_id = ?", orderID).First(&redemption).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	if err := tx.Model(&models.PromoCode{}).Where("id = ? AND usage_count > 0", redemption.PromoCodeID).
+		UpdateColumn("usage_count", gorm.Expr("usage_count - ?", 1)).Error; err != nil {
+		return err
+	}
+
+	return tx.Delete(&redemption).Error
+}
+
+// findPromoCode loads a promo code by code, optionally taking a row lock
+func (s *PromoService) findPromoCode(db *gorm.DB, code string, lock bool) (*models.PromoCode, error) {
+	query := db.Preload("Restaurants").Preload("Cuisines").Preload("Counties")
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var promo models.PromoCode
+	if err := query.Where("code = ?", normalizePromoCode(code)).First(&promo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("invalid promo code")
+		}
+		return nil, err
+	}
+
+	return &promo, nil
+}
+
+// evaluate checks every rule on a promo code and returns the discount it gives.
+// excludeOrderID is the order being redeemed against, which must not count as a prior order.
+func (s *PromoService) evaluate(db *gorm.DB, promo *models.PromoCode, userID, restaurantID uint, subTotal float64, excludeOrderID uint) (float64, error) {
+	now := time.Now()
+
+	if !promo.IsActive {
+		return 0, errors.New("promo code is no longer active")
+	}
+	if promo.StartsAt != nil && now.Before(*promo.StartsAt) {
+		return 0, errors.New("promo code is not yet valid")
+	}
+	if promo.ExpiresAt != nil && now.After(*promo.ExpiresAt) {
+		return 0, errors.New("promo code has expired")
+	}
+	if promo.UsageLimit > 0 && promo.UsageCount >= promo.UsageLimit {
+		return 0, errors.New("promo code has reached its usage limit")
+	}
+	if subTotal < promo.MinOrderAmount {
+		return 0, errors.New("order does not meet the minimum amount for this promo code")
+	}
+
+	if promo.PerUserLimit > 0 {
+		var used int64
+		if err := db.Model(&models.PromoRedemption{}).
+			Where("promo_code_id = ? AND user_id = ?", promo.ID, userID).
+			Count(&used).Error; err != nil {
+			return 0, err
+		}
+		if int(used) >= promo.PerUserLimit {
+			return 0, errors.New("you have already used this promo code")
+		}
+	}
+
+	if promo.FirstOrderOnly {
+		var previousOrders int64
+		query := db.Model(&models.Order{}).
+			Where("user_id = ? AND status NOT IN ?", userID,
+				[]models.OrderStatus{models.OrderStatusCancelled, models.OrderStatusRefunded})
+		if excludeOrderID != 0 {
+			query = query.Where("id <> ?", excludeOrderID)
+		}
+		if err := query.Count(&previousOrders).Error; err != nil {
+			return 0, err
+		}
+		if previousOrders > 0 {
+			return 0, errors.New("promo code is only valid on your first order")
+		}
+	}
+
+	if err := s.checkScope(db, promo, restaurantID); err != nil {
+		return 0, err
+	}
+
+	return calculateDiscount(promo, subTotal), nil
+}
+
+// checkScope verifies the restaurant falls within the promo code's restaurant, cuisine and county scoping
+func (s *PromoService) checkScope(db *gorm.DB, promo *models.PromoCode, restaurantID uint) error {
+	if len(promo.Restaurants) == 0 && len(promo.Cuisines) == 0 && len(promo.Counties) == 0 {
+		return nil
+	}
+
+	var restaurant models.Restaurant
+	if err := db.Preload("Cuisines").First(&restaurant, restaurantID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("restaurant not found")
+		}
+		return err
+	}
+
+	if len(promo.Restaurants) > 0 {
+		matched := false
+		for _, r := range promo.Restaurants {
+			if r.ID == restaurant.ID {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return errors.New("promo code is not valid for this restaurant")
+		}
+	}
+
+	if len(promo.Cuisines) > 0 {
+		matched := false
+		for _, pc := range promo.Cuisines {
+			for _, rc := range restaurant.Cuisines {
+				if pc.ID == rc.ID {
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			return errors.New("promo code is not valid for this cuisine")
+		}
+	}
+
+	if len(promo.Counties) > 0 {
+		matched := false
+		for _, county := range promo.Counties {
+			if strings.EqualFold(county.Name, restaurant.County) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return errors.New("promo code is not valid in this county")
+		}
+	}
+
+	return nil
+}
+
+// replaceScopes replaces a promo code's restaurant, cuisine and county associations
+func (s *PromoService) replaceScopes(tx *gorm.DB, promo *models.PromoCode, req *PromoCodeRequest) error {
+	var restaurants []models.Restaurant
+	if len(req.RestaurantIDs) > 0 {
+		if err := tx.Find(&restaurants, req.RestaurantIDs).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(promo).Association("Restaurants").Replace(restaurants); err != nil {
+		return err
+	}
+
+	var cuisines []models.Cuisine
+	if len(req.CuisineIDs) > 0 {
+		if err := tx.Find(&cuisines, req.CuisineIDs).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(promo).Association("Cuisines").Replace(cuisines); err != nil {
+		return err
+	}
+
+	var counties []models.County
+	if len(req.CountyIDs) > 0 {
+		if err := tx.Find(&counties, req.CountyIDs).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Model(promo).Association("Counties").Replace(counties)
+}
+
+// calculateDiscount computes the discount for a subtotal, respecting the cap
+func calculateDiscount(promo *models.PromoCode, subTotal float64) float64 {
+	var discount float64
+	switch promo.DiscountType {
+	case models.PromoDiscountPercentage:
+		discount = subTotal * promo.DiscountValue / 100
+	case models.PromoDiscountFixed:
+		discount = promo.DiscountValue
+	}
+
+	if promo.MaxDiscountAmount > 0 && discount > promo.MaxDiscountAmount {
+		discount = promo.MaxDiscountAmount
+	}
+	if discount > subTotal {
+		discount = subTotal
+	}
+
+	return roundAmount(discount)
+}
+
+// validatePromoRequest checks rules the binding tags cannot express
+func validatePromoRequest(req *PromoCodeRequest) error {
+	if req.DiscountType == models.PromoDiscountPercentage && req.DiscountValue > 100 {
+		return errors.New("percentage discount cannot exceed 100")
+	}
+	if req.StartsAt != nil && req.ExpiresAt != nil && !req.ExpiresAt.After(*req.StartsAt) {
+		return errors.New("expiry must be after the start date")
+	}
+	return nil
+}
+
+// applyPromoRequest copies request fields onto a promo code
+func applyPromoRequest(promo *models.PromoCode, req *PromoCodeRequest) {
+	promo.Description = req.Description
+	promo.DiscountType = req.DiscountType
+	promo.DiscountValue = req.DiscountValue
+	promo.MinOrderAmount = req.MinOrderAmount
+	promo.MaxDiscountAmount = req.MaxDiscountAmount
+	promo.StartsAt = req.StartsAt
+	promo.ExpiresAt = req.ExpiresAt
+	promo.UsageLimit = req.UsageLimit
+	promo.PerUserLimit = req.PerUserLimit
+	promo.FirstOrderOnly = req.FirstOrderOnly
+	if req.IsActive != nil {
+		promo.IsActive = *req.IsActive
+	}
+}
+
+// normalizePromoCode makes promo code lookups case-insensitive
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
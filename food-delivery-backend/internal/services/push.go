@@ -0,0 +1,294 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+	"kenyan-food-delivery/pkg/fcm"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidPushToken is returned by a PushSender when a token will never work again
+var ErrInvalidPushToken = errors.New("push token is no longer valid")
+
+// PushMessage is a notification sent to a device
+type PushMessage struct {
+	Title string
+	Body  string
+	Data  map[string]string // Delivered to the app for deep links
+}
+
+// PushSender delivers a push notification to a single device token
+type PushSender interface {
+	Name() string
+	Send(token string, platform models.DevicePlatform, message *PushMessage) error
+}
+
+// RegisterDeviceRequest registers the current device for push notifications
+type RegisterDeviceRequest struct {
+	Token      string                `json:"token" binding:"required,max=4096"`
+	Platform   models.DevicePlatform `json:"platform" binding:"required,oneof=android ios web"`
+	DeviceName string                `json:"device_name" binding:"max=100"`
+}
+
+// UnregisterDeviceRequest stops push notifications to a device
+type UnregisterDeviceRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// PushService keeps the registry of device tokens and fans push notifications out to them
+type PushService struct {
+	db     *gorm.DB
+	config *config.Config
+	sender PushSender
+}
+
+// NewPushService creates a new push service using the provider named in the config
+func NewPushService(db *gorm.DB, cfg *config.Config) *PushService {
+	return &PushService{
+		db:     db,
+		config: cfg,
+		sender: sharedPushSender(cfg),
+	}
+}
+
+var (
+	pushSender     PushSender
+	pushSenderOnce sync.Once
+)
+
+// sharedPushSender returns the process-wide sender, so the FCM access token is cached once
+// and a fake sender sees every message. The fake is never used in production: there, a
+// broken FCM setup fails every push instead of reporting it sent.
+func sharedPushSender(cfg *config.Config) PushSender {
+	pushSenderOnce.Do(func() {
+		if cfg.PushProvider == "fcm" {
+			client, err := fcm.NewClient(cfg.FCMCredentialsFile, cfg.FCMProjectID)
+			if err == nil {
+				pushSender = NewFCMSender(client)
+				return
+			}
+			log.Printf("Failed to configure FCM, push notifications will not be delivered: %v", err)
+		}
+		if cfg.Environment == "production" {
+			pushSender = unconfiguredPushSender{}
+			return
+		}
+		pushSender = NewFakePushSender(true)
+	})
+	return pushSender
+}
+
+// Sender returns the configured provider, e.g. to read a FakePushSender's messages in development
+func (s *PushService) Sender() PushSender {
+	return s.sender
+}
+
+// RegisterDevice stores a device token for the user. A token already registered to another
+// user moves to this one, since only the latest account signed in on a device should get its pushes.
+func (s *PushService) RegisterDevice(userID uint, req *RegisterDeviceRequest) (*models.DeviceToken, error) {
+	var device models.DeviceToken
+	err := s.db.Where("token = ?", req.Token).First(&device).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	device.UserID = userID
+	device.Token = req.Token
+	device.Platform = req.Platform
+	device.DeviceName = req.DeviceName
+	device.LastSeenAt = time.Now()
+	if err := s.db.Save(&device).Error; err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+// UnregisterDevice removes one of the user's device tokens
+func (s *PushService) UnregisterDevice(userID uint, token string) error {
+	result := s.db.Where("user_id = ? AND token = ?", userID, token).Delete(&models.DeviceToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("device not found")
+	}
+	return nil
+}
+
+// GetDevices lists the user's registered devices
+func (s *PushService) GetDevices(userID uint) ([]models.DeviceToken, error) {
+	var devices []models.DeviceToken
+	if err := s.db.Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// SendToUser delivers a message to every device of the user, forgetting tokens the provider
+// reports as invalid. It returns how many devices accepted the message.
+func (s *PushService) SendToUser(userID uint, message *PushMessage) (int, error) {
+	devices, err := s.GetDevices(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var invalid []uint
+	for _, device := range devices {
+		err := s.sender.Send(device.Token, device.Platform, message)
+		switch {
+		case err == nil:
+			sent++
+		case errors.Is(err, ErrInvalidPushToken):
+			invalid = append(invalid, device.ID)
+		default:
+			log.Printf("Failed to send push to device %d of user %d via %s: %v", device.ID, userID, s.sender.Name(), err)
+		}
+	}
+
+	if len(invalid) > 0 {
+		if err := s.db.Delete(&models.DeviceToken{}, invalid).Error; err != nil {
+			log.Printf("Failed to prune invalid push tokens of user %d: %v", userID, err)
+		} else {
+			log.Printf("Pruned %d invalid push tokens of user %d", len(invalid), userID)
+		}
+	}
+	return sent, nil
+}
+
+// FCMSender sends push notifications through Firebase Cloud Messaging
+type FCMSender struct {
+	client *fcm.Client
+}
+
+// NewFCMSender creates a sender backed by an FCM client
+func NewFCMSender(client *fcm.Client) *FCMSender {
+	return &FCMSender{client: client}
+}
+
+// Name identifies the provider in logs
+func (f *FCMSender) Name() string {
+	return "fcm"
+}
+
+// Send delivers a high-priority notification, reporting dead tokens as ErrInvalidPushToken
+func (f *FCMSender) Send(token string, platform models.DevicePlatform, message *PushMessage) error {
+	msg := &fcm.Message{
+		Token:        token,
+		Notification: &fcm.Notification{Title: message.Title, Body: message.Body},
+		Data:         message.Data,
+	}
+	switch platform {
+	case models.DevicePlatformAndroid:
+		msg.Android = &fcm.AndroidConfig{Priority: "high"}
+	case models.DevicePlatformIOS:
+		msg.APNS = &fcm.APNSConfig{Headers: map[string]string{"apns-priority": "10"}}
+	}
+
+	_, err := f.client.Send(msg)
+	var fcmErr *fcm.Error
+	if errors.As(err, &fcmErr) && fcmErr.InvalidToken() {
+		return fmt.Errorf("%w: %v", ErrInvalidPushToken, err)
+	}
+	return err
+}
+
+// unconfiguredPushSender fails every notification, for production without a working provider
+type unconfiguredPushSender struct{}
+
+// Name identifies the provider in logs
+func (unconfiguredPushSender) Name() string {
+	return "none"
+}
+
+// Send refuses to send
+func (unconfiguredPushSender) Send(token string, platform models.DevicePlatform, message *PushMessage) error {
+	return errors.New("no push provider is configured")
+}
+
+// maxFakePushMessages bounds the notifications a FakePushSender keeps, oldest dropped first
+const maxFakePushMessages = 500
+
+// FakePush is a notification captured by FakePushSender
+type FakePush struct {
+	Token    string                `json:"token"`
+	Platform models.DevicePlatform `json:"platform"`
+	Title    string                `json:"title"`
+	Body     string                `json:"body"`
+	Data     map[string]string     `json:"data"`
+	SentAt   time.Time             `json:"sent_at"`
+}
+
+// FakePushSender records notifications in memory instead of sending them, for tests and local development.
+// It is never used in production.
+type FakePushSender struct {
+	mu          sync.Mutex
+	messages    []FakePush // The most recent maxFakePushMessages
+	invalid     map[string]bool
+	logMessages bool
+}
+
+// NewFakePushSender creates a fake sender; logMessages also prints each notification to the log
+func NewFakePushSender(logMessages bool) *FakePushSender {
+	return &FakePushSender{invalid: make(map[string]bool), logMessages: logMessages}
+}
+
+// Name identifies the provider in logs
+func (f *FakePushSender) Name() string {
+	return "fake"
+}
+
+// Send records the notification, or rejects tokens marked invalid
+func (f *FakePushSender) Send(token string, platform models.DevicePlatform, message *PushMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.invalid[token] {
+		return ErrInvalidPushToken
+	}
+
+	f.messages = append(f.messages, FakePush{
+		Token:    token,
+		Platform: platform,
+		Title:    message.Title,
+		Body:     message.Body,
+		Data:     message.Data,
+		SentAt:   time.Now(),
+	})
+	if len(f.messages) > maxFakePushMessages {
+		f.messages = f.messages[len(f.messages)-maxFakePushMessages:]
+	}
+	if f.logMessages {
+		log.Printf("Push to %s device: %s - %s", platform, message.Title, message.Body)
+	}
+	return nil
+}
+
+// MarkInvalid makes later sends to the token fail as they would for an uninstalled app
+func (f *FakePushSender) MarkInvalid(token string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.invalid[token] = true
+}
+
+// Messages returns a copy of the recorded notifications
+func (f *FakePushSender) Messages() []FakePush {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakePush(nil), f.messages...)
+}
+
+// Reset clears the recorded notifications and invalid tokens
+func (f *FakePushSender) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages = nil
+	f.invalid = make(map[string]bool)
+}
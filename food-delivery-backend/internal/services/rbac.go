@@ -0,0 +1,615 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// roleCacheTTL bounds how long other instances keep serving a role's old permissions after an edit
+const roleCacheTTL = time.Minute
+
+// RBACService resolves permissions from roles and manages role assignments
+type RBACService struct {
+	db     *gorm.DB
+	config *config.Config
+	cache  *roleCache
+}
+
+// NewRBACService creates a new RBAC service
+func NewRBACService(db *gorm.DB, cfg *config.Config) *RBACService {
+	return &RBACService{
+		db:     db,
+		config: cfg,
+		cache:  sharedRoleCache(),
+	}
+}
+
+// CreateRoleRequest represents a custom role definition
+type CreateRoleRequest struct {
+	Name        string              `json:"name" binding:"required,min=3,max=64"`
+	Description string              `json:"description"`
+	Scope       models.RoleScope    `json:"scope" binding:"required,oneof=global restaurant self"`
+	Permissions []models.Permission `json:"permissions" binding:"required"`
+}
+
+// UpdateRoleRequest replaces a role's description and permissions
+type UpdateRoleRequest struct {
+	Description *string             `json:"description"`
+	Permissions []models.Permission `json:"permissions" binding:"required"`
+}
+
+// AssignRoleRequest names the role to give a user
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// UserRoles describes a user's primary role and additional assignments
+type UserRoles struct {
+	UserID      uint                        `json:"user_id"`
+	PrimaryRole models.UserRole             `json:"primary_role"`
+	Assignments []models.UserRoleAssignment `json:"assignments"`
+	Permissions []models.Permission         `json:"permissions"`
+}
+
+// HasPermission reports whether any of the user's roles grants the permission, whatever its scope
+func (s *RBACService) HasPermission(userID uint, permission models.Permission) (bool, error) {
+	roles, err := s.userRoles(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, role := range roles {
+		if role.grants(permission) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HasGlobalPermission reports whether one of the user's platform-wide roles grants the
+// permission; grants held only for restaurants or the user's own orders do not count
+func (s *RBACService) HasGlobalPermission(userID uint, permission models.Permission) (bool, error) {
+	roles, err := s.userRoles(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, role := range roles {
+		if role.scope == models.RoleScopeGlobal && role.grants(permission) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Authorize reports whether the user holds the permission for a specific resource.
+// Global roles apply everywhere; restaurant roles only to restaurants the user owns or
+// works at, within their staff role there; self roles only to the user's own orders and deliveries.
+func (s *RBACService) Authorize(userID uint, permission models.Permission, resourceType models.ResourceType, resourceID uint) (bool, error) {
+	roles, err := s.userRoles(userID)
+	if err != nil {
+		return false, err
+	}
+
+	scopes := make(map[models.RoleScope]bool)
+	for _, role := range roles {
+		if role.grants(permission) {
+			scopes[role.scope] = true
+		}
+	}
+	if scopes[models.RoleScopeGlobal] {
+		return true, nil
+	}
+
+	if scopes[models.RoleScopeRestaurant] {
+		restaurantID, err := s.restaurantFor(resourceType, resourceID)
+		if err != nil {
+			return false, err
+		}
+		if restaurantID != 0 {
+			ok, err := s.isRestaurantMember(userID, restaurantID, permission)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+	}
+
+	if scopes[models.RoleScopeSelf] && resourceType == models.ResourceOrder {
+		return s.isOrderParticipant(userID, resourceID)
+	}
+
+	return false, nil
+}
+
+// AuthorizeAPIKey reports whether a partner API key may use the permission on a resource:
+// the key must have it as a scope, the resource must belong to the key's restaurant, and
+// the user who created the key must still hold it there
+func (s *RBACService) AuthorizeAPIKey(key *models.APIKey, permission models.Permission, resourceType models.ResourceType, resourceID uint) (bool, error) {
+	if !key.Grants(permission) {
+		return false, nil
+	}
+
+	restaurantID, err := s.restaurantFor(resourceType, resourceID)
+	if err != nil || restaurantID != key.RestaurantID {
+		return false, err
+	}
+
+	return s.Authorize(key.CreatedBy, permission, resourceType, resourceID)
+}
+
+// HasGlobalRole reports whether any of the user's roles applies platform-wide
+func (s *RBACService) HasGlobalRole(userID uint) (bool, error) {
+	roles, err := s.userRoles(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, role := range roles {
+		if role.scope == models.RoleScopeGlobal {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CanAccessRestaurant is Authorize for a restaurant
+func (s *RBACService) CanAccessRestaurant(userID, restaurantID uint, permission models.Permission) (bool, error) {
+	return s.Authorize(userID, permission, models.ResourceRestaurant, restaurantID)
+}
+
+// CanAccessOrder is Authorize for an order
+func (s *RBACService) CanAccessOrder(userID, orderID uint, permission models.Permission) (bool, error) {
+	return s.Authorize(userID, permission, models.ResourceOrder, orderID)
+}
+
+// GetUserPermissions lists every permission granted to a user by any role
+func (s *RBACService) GetUserPermissions(userID uint) ([]models.Permission, error) {
+	roles, err := s.userRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	granted := make(map[models.Permission]bool)
+	for _, role := range roles {
+		if role.permissions[models.PermissionAll] {
+			return models.AllPermissions, nil
+		}
+		for permission := range role.permissions {
+			granted[permission] = true
+		}
+	}
+
+	permissions := make([]models.Permission, 0, len(granted))
+	for permission := range granted {
+		permissions = append(permissions, permission)
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i] < permissions[j] })
+	return permissions, nil
+}
+
+// GetRoles lists every role with its permissions
+func (s *RBACService) GetRoles() ([]models.Role, error) {
+	var roles []models.Role
+	if err := s.db.Preload("Permissions").Order("is_system DESC, name ASC").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// CreateRole adds a custom role
+func (s *RBACService) CreateRole(req *CreateRoleRequest) (*models.Role, error) {
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if err := validatePermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&models.Role{}).Where("name = ?", name).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("role already exists")
+	}
+
+	role := models.Role{
+		Name:        name,
+		Description: req.Description,
+		Scope:       req.Scope,
+	}
+	for _, permission := range uniquePermissions(req.Permissions) {
+		role.Permissions = append(role.Permissions, models.RolePermission{Permission: permission})
+	}
+	if err := s.db.Create(&role).Error; err != nil {
+		return nil, err
+	}
+
+	s.cache.invalidate()
+	return &role, nil
+}
+
+// UpdateRole replaces a role's permissions; the super admin role cannot be changed
+func (s *RBACService) UpdateRole(roleID uint, req *UpdateRoleRequest) (*models.Role, error) {
+	role, err := s.getRole(roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role.Name == string(models.RoleSuperAdmin) {
+		return nil, errors.New("the super admin role cannot be changed")
+	}
+	if err := validatePermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if req.Description != nil {
+			if err := tx.Model(role).Update("description", *req.Description).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		for _, permission := range uniquePermissions(req.Permissions) {
+			if err := tx.Create(&models.RolePermission{RoleID: role.ID, Permission: permission}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.invalidate()
+	return s.getRole(roleID)
+}
+
+// DeleteRole removes a custom role and its assignments
+func (s *RBACService) DeleteRole(roleID uint) error {
+	role, err := s.getRole(roleID)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return errors.New("built-in roles cannot be deleted")
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.UserRoleAssignment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(role).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.cache.invalidate()
+	return nil
+}
+
+// GetUserRoles returns a user's primary role, extra assignments and effective permissions
+func (s *RBACService) GetUserRoles(userID uint) (*UserRoles, error) {
+	var user models.User
+	if err := s.db.Select("id", "role").First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+
+	var assignments []models.UserRoleAssignment
+	if err := s.db.Preload("Role").Where("user_id = ?", userID).Order("created_at ASC").Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.GetUserPermissions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserRoles{
+		UserID:      user.ID,
+		PrimaryRole: user.Role,
+		Assignments: assignments,
+		Permissions: permissions,
+	}, nil
+}
+
+// AssignRole gives a user an additional role
+func (s *RBACService) AssignRole(actorID, userID uint, roleName string) (*models.UserRoleAssignment, error) {
+	var user models.User
+	if err := s.db.Select("id").First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+
+	var role models.Role
+	if err := s.db.Where("name = ?", strings.ToLower(strings.TrimSpace(roleName))).First(&role).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("role not found")
+		}
+		return nil, err
+	}
+
+	var existing int64
+	if err := s.db.Model(&models.UserRoleAssignment{}).Where("user_id = ? AND role_id = ?", userID, role.ID).Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, errors.New("user already has this role")
+	}
+
+	assignment := models.UserRoleAssignment{
+		UserID:     userID,
+		RoleID:     role.ID,
+		AssignedBy: &actorID,
+	}
+	if err := s.db.Create(&assignment).Error; err != nil {
+		return nil, err
+	}
+	assignment.Role = role
+
+	return &assignment, nil
+}
+
+// RemoveRole takes an additional role away from a user; the last super admin cannot be removed
+func (s *RBACService) RemoveRole(userID, roleID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var assignment models.UserRoleAssignment
+		if err := tx.Preload("Role").Where("user_id = ? AND role_id = ?", userID, roleID).First(&assignment).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.New("role assignment not found")
+			}
+			return err
+		}
+
+		if assignment.Role.Name == string(models.RoleSuperAdmin) {
+			var remaining int64
+			if err := tx.Model(&models.UserRoleAssignment{}).
+				Where("role_id = ? AND user_id <> ?", roleID, userID).
+				Count(&remaining).Error; err != nil {
+				return err
+			}
+			if remaining == 0 {
+				return errors.New("cannot remove the last super admin")
+			}
+		}
+
+		return tx.Delete(&assignment).Error
+	})
+}
+
+// PromoteSuperAdmins assigns the super admin role to the users with the given emails.
+// It bootstraps role management on a fresh install.
+func (s *RBACService) PromoteSuperAdmins(emails []string) error {
+	if len(emails) == 0 {
+		return nil
+	}
+
+	var role models.Role
+	if err := s.db.Where("name = ?", string(models.RoleSuperAdmin)).First(&role).Error; err != nil {
+		return err
+	}
+
+	var users []models.User
+	if err := s.db.Select("id", "email").Where("LOWER(email) IN ?", emails).Find(&users).Error; err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		var count int64
+		if err := s.db.Model(&models.UserRoleAssignment{}).Where("user_id = ? AND role_id = ?", user.ID, role.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		if err := s.db.Create(&models.UserRoleAssignment{UserID: user.ID, RoleID: role.ID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getRole loads a role with its permissions
+func (s *RBACService) getRole(roleID uint) (*models.Role, error) {
+	var role models.Role
+	if err := s.db.Preload("Permissions").First(&role, roleID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("role not found")
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// userRoles resolves the user's primary role and assigned roles to their cached permission sets
+func (s *RBACService) userRoles(userID uint) ([]*cachedRole, error) {
+	var names []string
+	if err := s.db.Raw(`SELECT role FROM users WHERE id = ? AND deleted_at IS NULL
+		UNION
+		SELECT roles.name FROM user_role_assignments JOIN roles ON roles.id = user_role_assignments.role_id
+		WHERE user_role_assignments.user_id = ?`, userID, userID).Scan(&names).Error; err != nil {
+		return nil, err
+	}
+
+	roles, err := s.cache.roles(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]*cachedRole, 0, len(names))
+	for _, name := range names {
+		if role, ok := roles[name]; ok {
+			resolved = append(resolved, role)
+		}
+	}
+	return resolved, nil
+}
+
+// restaurantFor finds the restaurant a resource belongs to; 0 if it has none
+func (s *RBACService) restaurantFor(resourceType models.ResourceType, resourceID uint) (uint, error) {
+	var restaurantIDs []uint
+	var err error
+
+	switch resourceType {
+	case models.ResourceRestaurant:
+		return resourceID, nil
+	case models.ResourceMenuItem:
+		err = s.db.Model(&models.MenuItem{}).Where("id = ?", resourceID).Pluck("restaurant_id", &restaurantIDs).Error
+	case models.ResourceOrder:
+		err = s.db.Model(&models.Order{}).Where("id = ?", resourceID).Pluck("restaurant_id", &restaurantIDs).Error
+	case models.ResourceReview:
+		err = s.db.Raw(`SELECT COALESCE(reviews.restaurant_id, menu_items.restaurant_id) FROM reviews
+			LEFT JOIN menu_items ON menu_items.id = reviews.menu_item_id
+			WHERE reviews.id = ? AND reviews.deleted_at IS NULL
+			AND COALESCE(reviews.restaurant_id, menu_items.restaurant_id) IS NOT NULL`, resourceID).Scan(&restaurantIDs).Error
+	default:
+		return 0, fmt.Errorf("unknown resource type %q", resourceType)
+	}
+
+	if err != nil || len(restaurantIDs) == 0 {
+		return 0, err
+	}
+	return restaurantIDs[0], nil
+}
+
+// isRestaurantMember reports whether the user owns the restaurant, or is active staff
+// there with a staff role that allows the permission
+func (s *RBACService) isRestaurantMember(userID, restaurantID uint, permission models.Permission) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.Restaurant{}).Where("id = ? AND owner_id = ?", restaurantID, userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	var staff models.RestaurantStaff
+	if err := s.db.Where("restaurant_id = ? AND user_id = ? AND status = ?", restaurantID, userID, models.StaffStatusActive).
+		First(&staff).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return staff.Grants(permission), nil
+}
+
+// isOrderParticipant reports whether the user placed or is delivering the order
+func (s *RBACService) isOrderParticipant(userID, orderID uint) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.Order{}).
+		Joins("LEFT JOIN deliveries ON deliveries.order_id = orders.id").
+		Where("orders.id = ? AND (orders.user_id = ? OR deliveries.driver_id = ?)", orderID, userID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// validatePermissions rejects permissions outside the catalogue, including the super admin wildcard
+func validatePermissions(permissions []models.Permission) error {
+	known := make(map[models.Permission]bool, len(models.AllPermissions))
+	for _, permission := range models.AllPermissions {
+		known[permission] = true
+	}
+	for _, permission := range permissions {
+		if !known[permission] {
+			return fmt.Errorf("unknown permission %q", permission)
+		}
+	}
+	return nil
+}
+
+// uniquePermissions drops duplicates while keeping order
+func uniquePermissions(permissions []models.Permission) []models.Permission {
+	seen := make(map[models.Permission]bool, len(permissions))
+	unique := make([]models.Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		if !seen[permission] {
+			seen[permission] = true
+			unique = append(unique, permission)
+		}
+	}
+	return unique
+}
+
+// cachedRole is a role's scope and permission set
+type cachedRole struct {
+	scope       models.RoleScope
+	permissions map[models.Permission]bool
+}
+
+// grants reports whether the role includes the permission
+func (r *cachedRole) grants(permission models.Permission) bool {
+	return r.permissions[models.PermissionAll] || r.permissions[permission]
+}
+
+// roleCache holds every role's permissions, reloaded after roleCacheTTL or an edit
+type roleCache struct {
+	mu       sync.RWMutex
+	byName   map[string]*cachedRole
+	loadedAt time.Time
+}
+
+var (
+	rbacRoleCache     *roleCache
+	rbacRoleCacheOnce sync.Once
+)
+
+// sharedRoleCache returns the process-wide cache so an edit is seen by every service
+func sharedRoleCache() *roleCache {
+	rbacRoleCacheOnce.Do(func() {
+		rbacRoleCache = &roleCache{}
+	})
+	return rbacRoleCache
+}
+
+// roles returns the cached roles, reloading them when stale
+func (c *roleCache) roles(db *gorm.DB) (map[string]*cachedRole, error) {
+	c.mu.RLock()
+	if c.byName != nil && time.Since(c.loadedAt) < roleCacheTTL {
+		defer c.mu.RUnlock()
+		return c.byName, nil
+	}
+	c.mu.RUnlock()
+
+	var roles []models.Role
+	if err := db.Preload("Permissions").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*cachedRole, len(roles))
+	for _, role := range roles {
+		cached := &cachedRole{scope: role.Scope, permissions: make(map[models.Permission]bool, len(role.Permissions))}
+		for _, rp := range role.Permissions {
+			cached.permissions[rp.Permission] = true
+		}
+		byName[role.Name] = cached
+	}
+
+	c.mu.Lock()
+	c.byName = byName
+	c.loadedAt = time.Now()
+	c.mu.Unlock()
+
+	return byName, nil
+}
+
+// invalidate forces the next lookup to reload roles
+func (c *roleCache) invalidate() {
+	c.mu.Lock()
+	c.byName = nil
+	c.mu.Unlock()
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"errors"
+
 	"kenyan-food-delivery/internal/config"
 	"kenyan-food-delivery/internal/models"
 
@@ -21,16 +23,12 @@ func NewRestaurantService(db *gorm.DB, cfg *config.Config) *RestaurantService {
 	}
 }
 
-// GetRestaurants gets all active restaurants with pagination
-func (s *RestaurantService) GetRestaurants(page, limit int, county string) ([]models.Restaurant, int64, error) {
+// GetRestaurants gets approved restaurants matching a listing query
+func (s *RestaurantService) GetRestaurants(q *RestaurantQuery) ([]models.Restaurant, int64, error) {
 	var restaurants []models.Restaurant
 	var total int64
 
-	query := s.db.Model(&models.Restaurant{}).Where("status = ?", models.RestaurantStatusApproved)
-	
-	if county != "" {
-		query = query.Where("county = ?", county)
-	}
+	query := q.Apply(s.db.Model(&models.Restaurant{}))
 
 	// Get total count
 	if err := query.Count(&total).Error; err != nil {
@@ -38,13 +36,15 @@ func (s *RestaurantService) GetRestaurants(page, limit int, county string) ([]mo
 	}
 
 	// Get paginated results
-	offset := (page - 1) * limit
-	if err := query.Preload("Categories").Preload("Cuisines").
-		Offset(offset).Limit(limit).
-		Order("rating DESC, total_orders DESC").
+	offset := (q.Page - 1) * q.Limit
+	if err := q.Order(query.Preload("Categories").Preload("Cuisines")).
+		Offset(offset).Limit(q.Limit).
 		Find(&restaurants).Error; err != nil {
 		return nil, 0, err
 	}
+	if err := annotateAvailability(s.db, restaurants); err != nil {
+		return nil, 0, err
+	}
 
 	return restaurants, total, nil
 }
@@ -58,60 +58,32 @@ func (s *RestaurantService) GetRestaurantByID(id uint) (*models.Restaurant, erro
 		return nil, err
 	}
 
+	load, err := restaurantLoad(s.db, &restaurant)
+	if err != nil {
+		return nil, err
+	}
+	restaurant.Availability = load.Availability
 	return &restaurant, nil
 }
 
-// SearchRestaurants searches restaurants by name or cuisine
-func (s *RestaurantService) SearchRestaurants(query string, page, limit int) ([]models.Restaurant, int64, error) {
-	var restaurants []models.Restaurant
-	var total int64
-
-	dbQuery := s.db.Model(&models.Restaurant{}).
-		Where("status = ? AND (name ILIKE ? OR description ILIKE ?)", 
-			models.RestaurantStatusApproved, "%"+query+"%", "%"+query+"%")
-
-	// Get total count
-	if err := dbQuery.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// Get paginated results
-	offset := (page - 1) * limit
-	if err := dbQuery.Preload("Categories").Preload("Cuisines").
-		Offset(offset).Limit(limit).
-		Order("rating DESC").
-		Find(&restaurants).Error; err != nil {
-		return nil, 0, err
-	}
-
-	return restaurants, total, nil
+// MenuItemStatusRequest represents a menu item availability toggle
+type MenuItemStatusRequest struct {
+	Status models.MenuItemStatus `json:"status" binding:"required,oneof=available unavailable out_of_stock"`
 }
 
-// GetRestaurantsByCuisine gets restaurants by cuisine type
-func (s *RestaurantService) GetRestaurantsByCuisine(cuisine string, page, limit int) ([]models.Restaurant, int64, error) {
-	var restaurants []models.Restaurant
-	var total int64
-
-	// Join with cuisines table
-	query := s.db.Model(&models.Restaurant{}).
-		Joins("JOIN restaurant_cuisine_mappings rcm ON restaurants.id = rcm.restaurant_id").
-		Joins("JOIN cuisines c ON rcm.cuisine_id = c.id").
-		Where("restaurants.status = ? AND c.name = ?", models.RestaurantStatusApproved, cuisine)
-
-	// Get total count
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+// SetMenuItemStatus marks a menu item available, unavailable or sold out
+func (s *RestaurantService) SetMenuItemStatus(itemID uint, req *MenuItemStatusRequest) (*models.MenuItem, error) {
+	var item models.MenuItem
+	if err := s.db.First(&item, itemID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("menu item not found")
+		}
+		return nil, err
 	}
 
-	// Get paginated results
-	offset := (page - 1) * limit
-	if err := query.Preload("Categories").Preload("Cuisines").
-		Offset(offset).Limit(limit).
-		Order("restaurants.rating DESC").
-		Find(&restaurants).Error; err != nil {
-		return nil, 0, err
+	item.Status = req.Status
+	if err := s.db.Model(&item).Update("status", req.Status).Error; err != nil {
+		return nil, err
 	}
-
-	return restaurants, total, nil
+	return &item, nil
 }
-
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Busy modes a restaurant can switch to
+const (
+	BusyModeNormal = "normal"
+	BusyModeBusy   = "busy"
+	BusyModePaused = "paused"
+)
+
+// kitchenLoadStatuses are the orders counted against a restaurant's capacity; ready orders
+// are waiting for a driver and no longer need the kitchen
+var kitchenLoadStatuses = []models.OrderStatus{
+	models.OrderStatusPending,
+	models.OrderStatusConfirmed,
+	models.OrderStatusPreparing,
+}
+
+// BusyModeRequest extends prep times or pauses new orders for a while
+type BusyModeRequest struct {
+	Mode             string `json:"mode" binding:"required,oneof=normal busy paused"`
+	Minutes          int    `json:"minutes" binding:"min=0,max=240"`            // How long until it returns to normal; not used for normal
+	ExtraPrepMinutes int    `json:"extra_prep_minutes" binding:"min=0,max=120"` // Added to prep estimates in busy mode
+}
+
+// OrderCapacityRequest sets how many orders a kitchen can work on at once
+type OrderCapacityRequest struct {
+	OrderCapacity *int `json:"order_capacity" binding:"required,min=0,max=1000"` // 0 for no limit
+}
+
+// RestaurantLoad is a restaurant's busy settings and how many orders it has in hand
+type RestaurantLoad struct {
+	RestaurantID      uint                           `json:"restaurant_id"`
+	OrderCapacity     int                            `json:"order_capacity"`
+	ActiveOrders      int64                          `json:"active_orders"`
+	BusyUntil         *time.Time                     `json:"busy_until"`
+	BusyExtraMinutes  int                            `json:"busy_extra_minutes"`
+	OrdersPausedUntil *time.Time                     `json:"orders_paused_until"`
+	Availability      *models.RestaurantAvailability `json:"availability"`
+}
+
+// GetRestaurantLoad returns a restaurant's busy settings and current availability
+func (s *RestaurantService) GetRestaurantLoad(restaurantID uint) (*RestaurantLoad, error) {
+	var restaurant models.Restaurant
+	if err := s.db.First(&restaurant, restaurantID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("restaurant not found")
+		}
+		return nil, err
+	}
+	return restaurantLoad(s.db, &restaurant)
+}
+
+// SetBusyMode switches a restaurant to busy or paused for the given minutes, after which it
+// returns to normal by itself, or back to normal straight away
+func (s *RestaurantService) SetBusyMode(restaurantID uint, req *BusyModeRequest) (*RestaurantLoad, error) {
+	now := time.Now()
+	until := now.Add(time.Duration(req.Minutes) * time.Minute)
+	updates := map[string]interface{}{
+		"busy_until":          nil,
+		"busy_extra_minutes":  0,
+		"orders_paused_until": nil,
+	}
+	switch req.Mode {
+	case BusyModeBusy:
+		if req.Minutes < 5 || req.ExtraPrepMinutes < 5 {
+			return nil, errors.New("busy mode needs minutes and extra_prep_minutes of at least 5")
+		}
+		updates["busy_until"] = until
+		updates["busy_extra_minutes"] = req.ExtraPrepMinutes
+	case BusyModePaused:
+		if req.Minutes < 5 {
+			return nil, errors.New("pausing orders needs minutes of at least 5")
+		}
+		updates["orders_paused_until"] = until
+	}
+
+	var restaurant models.Restaurant
+	if err := s.db.First(&restaurant, restaurantID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("restaurant not found")
+		}
+		return nil, err
+	}
+	if err := s.db.Model(&restaurant).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.First(&restaurant, restaurantID).Error; err != nil {
+		return nil, err
+	}
+	return restaurantLoad(s.db, &restaurant)
+}
+
+// SetOrderCapacity sets how many active orders a restaurant takes before new orders are
+// refused until some are finished
+func (s *RestaurantService) SetOrderCapacity(restaurantID uint, req *OrderCapacityRequest) (*RestaurantLoad, error) {
+	var restaurant models.Restaurant
+	if err := s.db.First(&restaurant, restaurantID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("restaurant not found")
+		}
+		return nil, err
+	}
+	if err := s.db.Model(&restaurant).Update("order_capacity", *req.OrderCapacity).Error; err != nil {
+		return nil, err
+	}
+	restaurant.OrderCapacity = *req.OrderCapacity
+	return restaurantLoad(s.db, &restaurant)
+}
+
+// restaurantLoad counts a restaurant's active orders and works out its availability
+func restaurantLoad(db *gorm.DB, restaurant *models.Restaurant) (*RestaurantLoad, error) {
+	counts, err := activeOrderCounts(db, []uint{restaurant.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &RestaurantLoad{
+		RestaurantID:      restaurant.ID,
+		OrderCapacity:     restaurant.OrderCapacity,
+		ActiveOrders:      counts[restaurant.ID],
+		BusyUntil:         restaurant.BusyUntil,
+		BusyExtraMinutes:  restaurant.BusyExtraMinutes,
+		OrdersPausedUntil: restaurant.OrdersPausedUntil,
+		Availability:      restaurantAvailability(restaurant, counts[restaurant.ID], time.Now()),
+	}, nil
+}
+
+// annotateAvailability sets Availability on restaurants for listings. Active orders are
+// only counted for restaurants with a capacity.
+func annotateAvailability(db *gorm.DB, restaurants []models.Restaurant) error {
+	var limited []uint
+	for _, restaurant := range restaurants {
+		if restaurant.OrderCapacity > 0 {
+			limited = append(limited, restaurant.ID)
+		}
+	}
+	counts, err := activeOrderCounts(db, limited)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range restaurants {
+		restaurants[i].Availability = restaurantAvailability(&restaurants[i], counts[restaurants[i].ID], now)
+	}
+	return nil
+}
+
+// activeOrderCounts counts the orders each restaurant's kitchen is still working on
+func activeOrderCounts(db *gorm.DB, restaurantIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(restaurantIDs))
+	if len(restaurantIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		RestaurantID uint
+		Count        int64
+	}
+	if err := db.Model(&models.Order{}).Select("restaurant_id, COUNT(*) AS count").
+		Where("restaurant_id IN ? AND status IN ?", restaurantIDs, kitchenLoadStatuses).
+		Group("restaurant_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.RestaurantID] = row.Count
+	}
+	return counts, nil
+}
+
+// restaurantAvailability works out whether a restaurant takes orders at a moment. Busy mode
+// and pauses past their end time count as normal, so neither needs clearing when it expires.
+func restaurantAvailability(restaurant *models.Restaurant, activeOrders int64, now time.Time) *models.RestaurantAvailability {
+	switch {
+	case !restaurant.IsOpen:
+		return &models.RestaurantAvailability{Status: models.AvailabilityClosed}
+	case restaurant.OrdersPausedUntil != nil && restaurant.OrdersPausedUntil.After(now):
+		return &models.RestaurantAvailability{Status: models.AvailabilityPaused, ResumesAt: restaurant.OrdersPausedUntil}
+	case restaurant.OrderCapacity > 0 && activeOrders >= int64(restaurant.OrderCapacity):
+		return &models.RestaurantAvailability{Status: models.AvailabilityAtCapacity}
+	case restaurant.BusyUntil != nil && restaurant.BusyUntil.After(now):
+		return &models.RestaurantAvailability{
+			Status:           models.AvailabilityBusy,
+			AcceptingOrders:  true,
+			ExtraPrepMinutes: restaurant.BusyExtraMinutes,
+			ResumesAt:        restaurant.BusyUntil,
+		}
+	default:
+		return &models.RestaurantAvailability{Status: models.AvailabilityOpen, AcceptingOrders: true}
+	}
+}
+
+// availabilityError explains to a customer at checkout why a restaurant is not taking orders
+func availabilityError(availability *models.RestaurantAvailability) error {
+	switch availability.Status {
+	case models.AvailabilityClosed:
+		return errors.New("restaurant is currently closed")
+	case models.AvailabilityPaused:
+		return fmt.Errorf("restaurant is not taking orders until %s", availability.ResumesAt.In(eastAfricaTime).Format("15:04"))
+	case models.AvailabilityAtCapacity:
+		return errors.New("restaurant is too busy to take more orders right now, please try again shortly")
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Restaurant listing sort options
+const (
+	RestaurantSortRating       = "rating"
+	RestaurantSortPopularity   = "popularity"
+	RestaurantSortDeliveryTime = "delivery_time"
+	RestaurantSortDistance     = "distance"
+)
+
+const maxRestaurantPageSize = 100
+
+// eastAfricaTime is used for "open now"; a fixed zone avoids depending on tzdata in containers
+var eastAfricaTime = time.FixedZone("EAT", 3*60*60)
+
+// RestaurantQuery is a validated, composable set of restaurant listing filters
+type RestaurantQuery struct {
+	Page            int
+	Limit           int
+	County          string
+	Cuisine         string
+	CategoryID      uint
+	OpenNow         bool
+	Vegetarian      bool
+	Vegan           bool
+	Halal           bool
+	MinPrice        *float64
+	MaxPrice        *float64
+	MinRating       *float64
+	FreeDelivery    bool
+	MaxDeliveryTime *int
+	Latitude        *float64
+	Longitude       *float64
+	Sort            string
+}
+
+// ParseRestaurantQuery validates listing query parameters
+func ParseRestaurantQuery(params url.Values) (*RestaurantQuery, error) {
+	q := &RestaurantQuery{
+		Page:    1,
+		Limit:   20,
+		County:  strings.TrimSpace(params.Get("county")),
+		Cuisine: strings.TrimSpace(params.Get("cuisine")),
+		Sort:    RestaurantSortRating,
+	}
+
+	var err error
+	if q.Page, err = parseIntParam(params, "page", q.Page); err != nil {
+		return nil, err
+	}
+	if q.Limit, err = parseIntParam(params, "limit", q.Limit); err != nil {
+		return nil, err
+	}
+	if q.Page < 1 {
+		return nil, fmt.Errorf("page must be at least 1")
+	}
+	if q.Limit < 1 || q.Limit > maxRestaurantPageSize {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxRestaurantPageSize)
+	}
+
+	if value := params.Get("category_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid category_id")
+		}
+		q.CategoryID = uint(id)
+	}
+
+	for name, target := range map[string]*bool{
+		"open_now":      &q.OpenNow,
+		"vegetarian":    &q.Vegetarian,
+		"vegan":         &q.Vegan,
+		"halal":         &q.Halal,
+		"free_delivery": &q.FreeDelivery,
+	} {
+		if value := params.Get(name); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s", name)
+			}
+			*target = parsed
+		}
+	}
+
+	if q.MinPrice, err = parseFloatParam(params, "min_price"); err != nil {
+		return nil, err
+	}
+	if q.MaxPrice, err = parseFloatParam(params, "max_price"); err != nil {
+		return nil, err
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil && *q.MinPrice > *q.MaxPrice {
+		return nil, fmt.Errorf("min_price cannot be greater than max_price")
+	}
+
+	if q.MinRating, err = parseFloatParam(params, "min_rating"); err != nil {
+		return nil, err
+	}
+	if q.MinRating != nil && (*q.MinRating < 0 || *q.MinRating > 5) {
+		return nil, fmt.Errorf("min_rating must be between 0 and 5")
+	}
+
+	if value := params.Get("max_delivery_time"); value != "" {
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes <= 0 {
+			return nil, fmt.Errorf("invalid max_delivery_time")
+		}
+		q.MaxDeliveryTime = &minutes
+	}
+
+	if q.Latitude, err = parseFloatParam(params, "lat"); err != nil {
+		return nil, err
+	}
+	if q.Longitude, err = parseFloatParam(params, "lon"); err != nil {
+		return nil, err
+	}
+	if (q.Latitude == nil) != (q.Longitude == nil) {
+		return nil, fmt.Errorf("lat and lon must be provided together")
+	}
+	if q.Latitude != nil && !validCoordinates(*q.Latitude, *q.Longitude) {
+		return nil, fmt.Errorf("lat must be between -90 and 90 and lon between -180 and 180")
+	}
+
+	if value := params.Get("sort"); value != "" {
+		switch value {
+		case RestaurantSortRating, RestaurantSortPopularity, RestaurantSortDeliveryTime:
+			q.Sort = value
+		case RestaurantSortDistance:
+			if q.Latitude == nil {
+				return nil, fmt.Errorf("lat and lon are required to sort by distance")
+			}
+			q.Sort = value
+		default:
+			return nil, fmt.Errorf("invalid sort option %q", value)
+		}
+	}
+
+	return q, nil
+}
+
+// Apply adds the query's filters to a restaurants query
+func (q *RestaurantQuery) Apply(db *gorm.DB) *gorm.DB {
+	db = db.Where("restaurants.status = ?", models.RestaurantStatusApproved)
+
+	if q.County != "" {
+		db = db.Where("restaurants.county = ?", q.County)
+	}
+
+	if q.Cuisine != "" {
+		db = db.Where(`EXISTS (SELECT 1 FROM restaurant_cuisine_mappings rcm
+			JOIN cuisines c ON c.id = rcm.cuisine_id
+			WHERE rcm.restaurant_id = restaurants.id AND (c.name = ? OR c.name_swahili = ?))`, q.Cuisine, q.Cuisine)
+	}
+
+	if q.CategoryID != 0 {
+		db = db.Where(`EXISTS (SELECT 1 FROM restaurant_category_mappings rcat
+			WHERE rcat.restaurant_id = restaurants.id AND rcat.restaurant_category_id = ?)`, q.CategoryID)
+	}
+
+	if q.OpenNow {
+		now := time.Now().In(eastAfricaTime).Format("15:04")
+		db = db.Where("restaurants.is_open = ?", true).
+			Where(`(COALESCE(restaurants.opening_time, '') = '' OR COALESCE(restaurants.closing_time, '') = ''
+				OR (restaurants.opening_time <= restaurants.closing_time AND ? BETWEEN restaurants.opening_time AND restaurants.closing_time)
+				OR (restaurants.opening_time > restaurants.closing_time AND (? >= restaurants.opening_time OR ? <= restaurants.closing_time)))`,
+				now, now, now).
+			Where("restaurants.orders_paused_until IS NULL OR restaurants.orders_paused_until <= ?", time.Now())
+	}
+
+	for column, enabled := range map[string]bool{
+		"is_vegetarian": q.Vegetarian,
+		"is_vegan":      q.Vegan,
+		"is_halal":      q.Halal,
+	} {
+		if enabled {
+			db = db.Where(fmt.Sprintf(`EXISTS (SELECT 1 FROM menu_items m
+				WHERE m.restaurant_id = restaurants.id AND m.deleted_at IS NULL
+				AND m.status = ? AND m.%s = true)`, column), models.MenuItemStatusAvailable)
+		}
+	}
+
+	if q.MinPrice != nil || q.MaxPrice != nil {
+		low, high := 0.0, 1e12
+		if q.MinPrice != nil {
+			low = *q.MinPrice
+		}
+		if q.MaxPrice != nil {
+			high = *q.MaxPrice
+		}
+		// Price range is matched against the restaurant's average available dish price
+		db = db.Where(`(SELECT AVG(COALESCE(m.discount_price, m.price)) FROM menu_items m
+			WHERE m.restaurant_id = restaurants.id AND m.deleted_at IS NULL AND m.status = ?) BETWEEN ? AND ?`,
+			models.MenuItemStatusAvailable, low, high)
+	}
+
+	if q.MinRating != nil {
+		db = db.Where("restaurants.rating >= ?", *q.MinRating)
+	}
+
+	if q.FreeDelivery {
+		db = db.Where("restaurants.free_delivery = ?", true)
+	}
+
+	if q.MaxDeliveryTime != nil {
+		db = db.Where("restaurants.delivery_time > 0 AND restaurants.delivery_time <= ?", *q.MaxDeliveryTime)
+	}
+
+	return db
+}
+
+// Order adds the query's sort order to a restaurants query
+func (q *RestaurantQuery) Order(db *gorm.DB) *gorm.DB {
+	switch q.Sort {
+	case RestaurantSortPopularity:
+		return db.Order("restaurants.total_orders DESC, restaurants.rating DESC")
+	case RestaurantSortDeliveryTime:
+		return db.Order("NULLIF(restaurants.delivery_time, 0) ASC NULLS LAST, restaurants.rating DESC")
+	case RestaurantSortDistance:
+		// Equirectangular approximation is monotonic enough for ordering and avoids trig on every row
+		return db.Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "power(restaurants.latitude - ?, 2) + power((restaurants.longitude - ?) * cos(radians(?)), 2) ASC",
+			Vars:               []interface{}{*q.Latitude, *q.Longitude, *q.Latitude},
+			WithoutParentheses: true,
+		}})
+	default:
+		return db.Order("restaurants.rating DESC, restaurants.total_orders DESC")
+	}
+}
+
+// AppliedFilters describes the filters in effect, for echoing back to clients
+func (q *RestaurantQuery) AppliedFilters() map[string]interface{} {
+	applied := map[string]interface{}{}
+	if q.County != "" {
+		applied["county"] = q.County
+	}
+	if q.Cuisine != "" {
+		applied["cuisine"] = q.Cuisine
+	}
+	if q.CategoryID != 0 {
+		applied["category_id"] = q.CategoryID
+	}
+	if q.OpenNow {
+		applied["open_now"] = true
+	}
+	if q.Vegetarian {
+		applied["vegetarian"] = true
+	}
+	if q.Vegan {
+		applied["vegan"] = true
+	}
+	if q.Halal {
+		applied["halal"] = true
+	}
+	if q.MinPrice != nil {
+		applied["min_price"] = *q.MinPrice
+	}
+	if q.MaxPrice != nil {
+		applied["max_price"] = *q.MaxPrice
+	}
+	if q.MinRating != nil {
+		applied["min_rating"] = *q.MinRating
+	}
+	if q.FreeDelivery {
+		applied["free_delivery"] = true
+	}
+	if q.MaxDeliveryTime != nil {
+		applied["max_delivery_time"] = *q.MaxDeliveryTime
+	}
+	if q.Latitude != nil {
+		applied["lat"] = *q.Latitude
+		applied["lon"] = *q.Longitude
+	}
+	return applied
+}
+
+// parseIntParam reads an optional integer query parameter
+func parseIntParam(params url.Values, name string, fallback int) (int, error) {
+	value := params.Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+	return parsed, nil
+}
+
+// parseFloatParam reads an optional finite, non-negative float query parameter
+func parseFloatParam(params url.Values, name string) (*float64, error) {
+	value := params.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+		return nil, fmt.Errorf("invalid %s", name)
+	}
+	if parsed < 0 && name != "lat" && name != "lon" {
+		return nil, fmt.Errorf("%s cannot be negative", name)
+	}
+	return &parsed, nil
+}
@@ -0,0 +1,406 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxReviewImages limits how many photos can be attached to a single review
+const maxReviewImages = 5
+
+// ReviewService handles customer reviews and rating aggregates
+type ReviewService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewReviewService creates a new review service
+func NewReviewService(db *gorm.DB, cfg *config.Config) *ReviewService {
+	return &ReviewService{
+		db:     db,
+		config: cfg,
+	}
+}
+
+// RatingRequest represents a single rating with an optional comment
+type RatingRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment"`
+}
+
+// ItemRatingRequest represents a rating for one menu item in an order
+type ItemRatingRequest struct {
+	MenuItemID uint   `json:"menu_item_id" binding:"required"`
+	Rating     int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment    string `json:"comment"`
+}
+
+// OrderReviewRequest represents a review of a delivered order
+type OrderReviewRequest struct {
+	Restaurant RatingRequest       `json:"restaurant" binding:"required"`
+	Items      []ItemRatingRequest `json:"items" binding:"dive"`
+	Driver     *RatingRequest      `json:"driver"`
+	Images     []string            `json:"images"`
+}
+
+// OwnerReplyRequest represents a restaurant owner's public reply
+type OwnerReplyRequest struct {
+	Reply string `json:"reply" binding:"required,max=1000"`
+}
+
+// OrderReviewResponse groups the reviews created for an order
+type OrderReviewResponse struct {
+	Restaurant *models.Review  `json:"restaurant"`
+	Items      []models.Review `json:"items"`
+	Driver     *models.Review  `json:"driver,omitempty"`
+}
+
+// ReviewOrder reviews a delivered order's restaurant, items and optionally its driver.
+// Restaurant and menu item aggregates are updated in the same transaction, which holds a
+// lock on the order so a double submission cannot review it twice.
+func (s *ReviewService) ReviewOrder(userID, orderID uint, req *OrderReviewRequest) (*OrderReviewResponse, error) {
+	if len(req.Images) > maxReviewImages {
+		return nil, errors.New("too many images attached to review")
+	}
+
+	var order models.Order
+	if err := s.db.Preload("OrderItems").Preload("Delivery").
+		Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("order not found")
+		}
+		return nil, err
+	}
+
+	if order.Status != models.OrderStatusDelivered {
+		return nil, errors.New("only delivered orders can be reviewed")
+	}
+
+	orderedItems := make(map[uint]bool, len(order.OrderItems))
+	for _, item := range order.OrderItems {
+		orderedItems[item.MenuItemID] = true
+	}
+	reviewedItems := make(map[uint]bool, len(req.Items))
+	for _, item := range req.Items {
+		if !orderedItems[item.MenuItemID] {
+			return nil, errors.New("can only review items included in the order")
+		}
+		if reviewedItems[item.MenuItemID] {
+			return nil, errors.New("each item can only be reviewed once")
+		}
+		reviewedItems[item.MenuItemID] = true
+	}
+
+	var driverID *uint
+	if req.Driver != nil {
+		if order.Delivery == nil || order.Delivery.DriverID == nil {
+			return nil, errors.New("order has no driver to review")
+		}
+		driverID = order.Delivery.DriverID
+	}
+
+	images, err := encodeReviewImages(req.Images)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &OrderReviewResponse{}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the order so concurrent submissions for it are checked one at a time
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Order{}, order.ID).Error; err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.Review{}).Where("order_id = ? AND user_id = ?", order.ID, userID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errors.New("order has already been reviewed")
+		}
+
+		restaurantReview := &models.Review{
+			UserID:       userID,
+			RestaurantID: &order.RestaurantID,
+			OrderID:      &order.ID,
+			Rating:       req.Restaurant.Rating,
+			Comment:      req.Restaurant.Comment,
+			Images:       images,
+			IsVerified:   true, // Tied to the reviewer's own delivered order
+		}
+		if err := tx.Create(restaurantReview).Error; err != nil {
+			return err
+		}
+		response.Restaurant = restaurantReview
+
+		for _, item := range req.Items {
+			menuItemID := item.MenuItemID
+			itemReview := models.Review{
+				UserID:       userID,
+				RestaurantID: &order.RestaurantID,
+				MenuItemID:   &menuItemID,
+				OrderID:      &order.ID,
+				Rating:       item.Rating,
+				Comment:      item.Comment,
+				IsVerified:   true,
+			}
+			if err := tx.Create(&itemReview).Error; err != nil {
+				return err
+			}
+			if err := refreshMenuItemRating(tx, menuItemID); err != nil {
+				return err
+			}
+			response.Items = append(response.Items, itemReview)
+		}
+
+		if driverID != nil {
+			driverReview := &models.Review{
+				UserID:     userID,
+				DriverID:   driverID,
+				OrderID:    &order.ID,
+				Rating:     req.Driver.Rating,
+				Comment:    req.Driver.Comment,
+				IsVerified: true,
+			}
+			if err := tx.Create(driverReview).Error; err != nil {
+				return err
+			}
+			response.Driver = driverReview
+		}
+
+		return refreshRestaurantRating(tx, order.RestaurantID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetRestaurantReviews lists restaurant-level reviews, or reviews of one menu item when menuItemID is set
+func (s *ReviewService) GetRestaurantReviews(restaurantID uint, menuItemID *uint, page, limit int) ([]models.Review, int64, error) {
+	var reviews []models.Review
+	var total int64
+
+	query := s.db.Model(&models.Review{}).Where("restaurant_id = ?", restaurantID)
+	if menuItemID != nil {
+		query = query.Where("menu_item_id = ?", *menuItemID)
+	} else {
+		query = query.Where("menu_item_id IS NULL")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "first_name", "last_name", "profile_picture")
+	}).
+		Offset(offset).Limit(limit).
+		Order("is_helpful DESC, created_at DESC").
+		Find(&reviews).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, total, nil
+}
+
+// MarkHelpful records a helpful vote; each user can vote once per review
+func (s *ReviewService) MarkHelpful(userID, reviewID uint) (*models.Review, error) {
+	var review models.Review
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, reviewID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.New("review not found")
+			}
+			return err
+		}
+
+		if review.UserID == userID {
+			return errors.New("you cannot vote on your own review")
+		}
+
+		vote := &models.ReviewVote{ReviewID: reviewID, UserID: userID}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(vote)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("you have already marked this review as helpful")
+		}
+
+		review.IsHelpful++
+		return tx.Model(&review).UpdateColumn("is_helpful", review.IsHelpful).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// RemoveHelpful withdraws a helpful vote
+func (s *ReviewService) RemoveHelpful(userID, reviewID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("review_id = ? AND user_id = ?", reviewID, userID).Delete(&models.ReviewVote{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("vote not found")
+		}
+
+		return tx.Model(&models.Review{}).Where("id = ? AND is_helpful > 0", reviewID).
+			UpdateColumn("is_helpful", gorm.Expr("is_helpful - ?", 1)).Error
+	})
+}
+
+// AddReviewImage attaches an uploaded image URL to the reviewer's own review
+func (s *ReviewService) AddReviewImage(userID, reviewID uint, imageURL string) (*models.Review, error) {
+	var review models.Review
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the row so concurrent uploads can't both pass the image cap
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", reviewID, userID).First(&review).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.New("review not found")
+			}
+			return err
+		}
+
+		images, err := decodeReviewImages(review.Images)
+		if err != nil {
+			return err
+		}
+
+		encoded, err := encodeReviewImages(append(images, imageURL))
+		if err != nil {
+			return err
+		}
+
+		review.Images = encoded
+		return tx.Model(&review).Update("images", encoded).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// ReplyToReview posts or replaces the restaurant's public reply on a review.
+// Callers are authorized for reviews:reply on the review's restaurant by the route.
+func (s *ReviewService) ReplyToReview(reviewID uint, req *OwnerReplyRequest) (*models.Review, error) {
+	var review models.Review
+	if err := s.db.First(&review, reviewID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("review not found")
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	review.OwnerReply = strings.TrimSpace(req.Reply)
+	review.OwnerRepliedAt = &now
+	if err := s.db.Model(&review).Updates(map[string]interface{}{
+		"owner_reply":      review.OwnerReply,
+		"owner_replied_at": review.OwnerRepliedAt,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// refreshRestaurantRating recalculates a restaurant's rating from its restaurant-level reviews
+func refreshRestaurantRating(tx *gorm.DB, restaurantID uint) error {
+	// Lock the row so concurrent reviews serialise their aggregate updates
+	var restaurant models.Restaurant
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&restaurant, restaurantID).Error; err != nil {
+		return err
+	}
+
+	var stats struct {
+		Average float64
+		Count   int
+	}
+	if err := tx.Model(&models.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+		Where("restaurant_id = ? AND menu_item_id IS NULL", restaurantID).
+		Scan(&stats).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&restaurant).Updates(map[string]interface{}{
+		"rating":        roundAmount(stats.Average),
+		"total_reviews": stats.Count,
+	}).Error
+}
+
+// refreshMenuItemRating recalculates a menu item's rating from its reviews
+func refreshMenuItemRating(tx *gorm.DB, menuItemID uint) error {
+	var menuItem models.MenuItem
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&menuItem, menuItemID).Error; err != nil {
+		return err
+	}
+
+	var stats struct {
+		Average float64
+		Count   int
+	}
+	if err := tx.Model(&models.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+		Where("menu_item_id = ?", menuItemID).
+		Scan(&stats).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&menuItem).Updates(map[string]interface{}{
+		"rating":        roundAmount(stats.Average),
+		"total_reviews": stats.Count,
+	}).Error
+}
+
+// encodeReviewImages stores image URLs as the JSON array kept in Review.Images
+func encodeReviewImages(images []string) (string, error) {
+	if len(images) == 0 {
+		return "", nil
+	}
+	if len(images) > maxReviewImages {
+		return "", errors.New("too many images attached to review")
+	}
+	for _, image := range images {
+		if !strings.HasPrefix(image, "https://") {
+			return "", errors.New("review images must be https URLs")
+		}
+	}
+
+	data, err := json.Marshal(images)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// decodeReviewImages reads the JSON array kept in Review.Images
+func decodeReviewImages(images string) ([]string, error) {
+	if images == "" {
+		return nil, nil
+	}
+
+	var urls []string
+	if err := json.Unmarshal([]byte(images), &urls); err != nil {
+		return nil, err
+	}
+	return urls, nil
+}
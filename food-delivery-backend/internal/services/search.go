@@ -0,0 +1,263 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// maxDishHitsPerRestaurant caps how many matching dishes are returned under each restaurant
+const maxDishHitsPerRestaurant = 5
+
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+// Errors for search queries the client can correct
+var (
+	ErrSearchQueryTooShort = errors.New("search query must be at least 2 characters")
+	ErrSearchQueryTooLong  = errors.New("search query is too long")
+)
+
+// SearchService handles full-text and fuzzy search across restaurants, dishes and cuisines
+type SearchService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewSearchService creates a new search service
+func NewSearchService(db *gorm.DB, cfg *config.Config) *SearchService {
+	return &SearchService{
+		db:     db,
+		config: cfg,
+	}
+}
+
+// DishHit represents a menu item that matched a search
+type DishHit struct {
+	ID          uint    `json:"id"`
+	Name        string  `json:"name"`
+	NameSwahili string  `json:"name_swahili"`
+	Price       float64 `json:"price"`
+	Image       string  `json:"image"`
+	Score       float64 `json:"score"`
+}
+
+// RestaurantHit represents a ranked restaurant with the dishes that matched under it
+type RestaurantHit struct {
+	Restaurant    models.Restaurant `json:"restaurant"`
+	Score         float64           `json:"score"`
+	MatchedDishes []DishHit         `json:"matched_dishes"`
+}
+
+// searchTerms holds the query in the forms used by the search SQL
+type searchTerms struct {
+	Raw     string
+	Compact string
+}
+
+// searchQueryCTE scores approved restaurants by their own text, their dishes and their cuisines.
+// Dish and cuisine matches are weighted slightly lower than direct restaurant matches.
+const searchQueryCTE = `
+WITH q AS (
+	SELECT websearch_to_tsquery('english', @raw) || websearch_to_tsquery('simple', @raw) AS tsq,
+		lower(@raw) AS raw,
+		@compact AS compact
+),
+restaurant_hits AS (
+	SELECT r.id AS restaurant_id,
+		GREATEST(
+			ts_rank(r.search_vector, q.tsq),
+			similarity(lower(r.name), q.raw),
+			similarity(regexp_replace(lower(r.name), '\s+', '', 'g'), q.compact)
+		) AS score
+	FROM restaurants r, q
+	WHERE r.search_vector @@ q.tsq
+		OR lower(r.name) % q.raw
+		OR regexp_replace(lower(r.name), '\s+', '', 'g') % q.compact
+),
+dish_hits AS (
+	SELECT m.restaurant_id, m.id AS menu_item_id,
+		0.9 * GREATEST(
+			ts_rank(m.search_vector, q.tsq),
+			similarity(lower(m.name), q.raw),
+			similarity(lower(coalesce(m.name_swahili, '')), q.raw),
+			similarity(regexp_replace(lower(m.name), '\s+', '', 'g'), q.compact)
+		) AS score
+	FROM menu_items m, q
+	WHERE m.deleted_at IS NULL AND m.status = 'available'
+		AND (m.search_vector @@ q.tsq
+			OR lower(m.name) % q.raw
+			OR lower(m.name_swahili) % q.raw
+			OR regexp_replace(lower(m.name), '\s+', '', 'g') % q.compact)
+),
+cuisine_hits AS (
+	SELECT rcm.restaurant_id,
+		0.7 * GREATEST(
+			ts_rank(c.search_vector, q.tsq),
+			similarity(lower(c.name), q.raw),
+			similarity(lower(coalesce(c.name_swahili, '')), q.raw)
+		) AS score
+	FROM cuisines c
+	JOIN restaurant_cuisine_mappings rcm ON rcm.cuisine_id = c.id, q
+	WHERE c.search_vector @@ q.tsq
+		OR lower(c.name) % q.raw
+		OR lower(c.name_swahili) % q.raw
+),
+combined AS (
+	SELECT hits.restaurant_id, MAX(hits.score) AS score
+	FROM (
+		SELECT restaurant_id, score FROM restaurant_hits
+		UNION ALL SELECT restaurant_id, score FROM dish_hits
+		UNION ALL SELECT restaurant_id, score FROM cuisine_hits
+	) hits
+	JOIN restaurants r ON r.id = hits.restaurant_id
+	WHERE r.deleted_at IS NULL AND r.status = @status
+	GROUP BY hits.restaurant_id
+)`
+
+// Search finds restaurants matching a query in English or Swahili, ranked by relevance,
+// with matching dishes grouped under their restaurant
+func (s *SearchService) Search(query string, page, limit int) ([]RestaurantHit, int64, error) {
+	terms, err := newSearchTerms(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	params := map[string]interface{}{
+		"raw":     terms.Raw,
+		"compact": terms.Compact,
+		"status":  models.RestaurantStatusApproved,
+		"limit":   limit,
+		"offset":  (page - 1) * limit,
+	}
+
+	var ranked []struct {
+		RestaurantID uint
+		Score        float64
+		Total        int64
+	}
+	if err := s.db.Raw(searchQueryCTE+`
+		SELECT combined.restaurant_id, combined.score, COUNT(*) OVER () AS total
+		FROM combined
+		JOIN restaurants r ON r.id = combined.restaurant_id
+		ORDER BY combined.score DESC, r.rating DESC, r.total_orders DESC
+		LIMIT @limit OFFSET @offset`, params).Scan(&ranked).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if len(ranked) == 0 {
+		return []RestaurantHit{}, 0, nil
+	}
+
+	restaurantIDs := make([]uint, 0, len(ranked))
+	for _, row := range ranked {
+		restaurantIDs = append(restaurantIDs, row.RestaurantID)
+	}
+
+	var restaurants []models.Restaurant
+	if err := s.db.Preload("Categories").Preload("Cuisines").
+		Where("id IN ?", restaurantIDs).Find(&restaurants).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := annotateAvailability(s.db, restaurants); err != nil {
+		return nil, 0, err
+	}
+	restaurantsByID := make(map[uint]models.Restaurant, len(restaurants))
+	for _, restaurant := range restaurants {
+		restaurantsByID[restaurant.ID] = restaurant
+	}
+
+	dishes, err := s.dishHits(terms, restaurantIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hits := make([]RestaurantHit, 0, len(ranked))
+	for _, row := range ranked {
+		restaurant, ok := restaurantsByID[row.RestaurantID]
+		if !ok {
+			continue
+		}
+		matched := dishes[row.RestaurantID]
+		if matched == nil {
+			matched = []DishHit{}
+		}
+		hits = append(hits, RestaurantHit{
+			Restaurant:    restaurant,
+			Score:         row.Score,
+			MatchedDishes: matched,
+		})
+	}
+
+	return hits, ranked[0].Total, nil
+}
+
+// dishHits loads the best matching dishes for each restaurant on the current page
+func (s *SearchService) dishHits(terms *searchTerms, restaurantIDs []uint) (map[uint][]DishHit, error) {
+	var rows []struct {
+		RestaurantID uint
+		DishHit
+	}
+	if err := s.db.Raw(`
+		WITH q AS (
+			SELECT websearch_to_tsquery('english', @raw) || websearch_to_tsquery('simple', @raw) AS tsq,
+				lower(@raw) AS raw,
+				@compact AS compact
+		),
+		ranked AS (
+			SELECT m.restaurant_id, m.id, m.name, m.name_swahili, COALESCE(m.discount_price, m.price) AS price, m.image,
+				GREATEST(
+					ts_rank(m.search_vector, q.tsq),
+					similarity(lower(m.name), q.raw),
+					similarity(lower(coalesce(m.name_swahili, '')), q.raw),
+					similarity(regexp_replace(lower(m.name), '\s+', '', 'g'), q.compact)
+				) AS score
+			FROM menu_items m, q
+			WHERE m.restaurant_id IN @restaurant_ids
+				AND m.deleted_at IS NULL AND m.status = 'available'
+				AND (m.search_vector @@ q.tsq
+					OR lower(m.name) % q.raw
+					OR lower(m.name_swahili) % q.raw
+					OR regexp_replace(lower(m.name), '\s+', '', 'g') % q.compact)
+		)
+		SELECT restaurant_id, id, name, name_swahili, price, image, score
+		FROM (
+			SELECT ranked.*, ROW_NUMBER() OVER (PARTITION BY restaurant_id ORDER BY score DESC) AS position
+			FROM ranked
+		) dishes
+		WHERE position <= @per_restaurant
+		ORDER BY restaurant_id, score DESC`, map[string]interface{}{
+		"raw":            terms.Raw,
+		"compact":        terms.Compact,
+		"restaurant_ids": restaurantIDs,
+		"per_restaurant": maxDishHitsPerRestaurant,
+	}).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	dishes := make(map[uint][]DishHit)
+	for _, row := range rows {
+		dishes[row.RestaurantID] = append(dishes[row.RestaurantID], row.DishHit)
+	}
+	return dishes, nil
+}
+
+// newSearchTerms normalises a user query for full-text and trigram matching
+func newSearchTerms(query string) (*searchTerms, error) {
+	raw := strings.TrimSpace(whitespacePattern.ReplaceAllString(query, " "))
+	if len([]rune(raw)) < 2 {
+		return nil, ErrSearchQueryTooShort
+	}
+	if len([]rune(raw)) > 100 {
+		return nil, ErrSearchQueryTooLong
+	}
+
+	return &searchTerms{
+		Raw:     raw,
+		Compact: strings.ToLower(whitespacePattern.ReplaceAllString(raw, "")),
+	}, nil
+}
@@ -8,32 +8,61 @@ import (
 
 // Services holds all service instances
 type Services struct {
-	User       *UserService
-	Restaurant *RestaurantService
-	Order      *OrderService
-	Payment    *PaymentService
-	Delivery   *DeliveryService
-	Auth       *AuthService
-	Email      *EmailService
-	Cloudinary *CloudinaryService
-	Upload     *UploadService
+	User         *UserService
+	Restaurant   *RestaurantService
+	Order        *OrderService
+	Payment      *PaymentService
+	Delivery     *DeliveryService
+	Auth         *AuthService
+	Email        *EmailService
+	Cloudinary   *CloudinaryService
+	Upload       *UploadService
+	Promo        *PromoService
+	Review       *ReviewService
+	Search       *SearchService
+	Session      *SessionService
+	SMS          *SMSService
+	Lockout      *LockoutService
+	RBAC         *RBACService
+	Staff        *StaffService
+	MFA          *MFAService
+	Token        *TokenService
+	Notification *NotificationService
+	Push         *PushService
+	Outbox       *OutboxService
+	Webhook      *WebhookService
+	APIKey       *APIKeyService
 }
 
 // New creates a new services instance
 func New(db *gorm.DB, cfg *config.Config) *Services {
 	cloudinaryService, _ := NewCloudinaryService(cfg) // Handle error in real application
-	uploadService, _ := NewUploadService(cfg) // Handle error in real application
+	uploadService, _ := NewUploadService(cfg)         // Handle error in real application
 
 	return &Services{
-		User:       NewUserService(db, cfg),
-		Restaurant: NewRestaurantService(db, cfg),
-		Order:      NewOrderService(db, cfg),
-		Payment:    NewPaymentService(db, cfg),
-		Delivery:   NewDeliveryService(db, cfg),
-		Auth:       NewAuthService(db, cfg),
-		Email:      NewEmailService(cfg),
-		Cloudinary: cloudinaryService,
-		Upload:     uploadService,
+		User:         NewUserService(db, cfg),
+		Restaurant:   NewRestaurantService(db, cfg),
+		Order:        NewOrderService(db, cfg),
+		Payment:      NewPaymentService(db, cfg),
+		Delivery:     NewDeliveryService(db, cfg),
+		Auth:         NewAuthService(db, cfg),
+		Email:        NewEmailService(db, cfg),
+		Cloudinary:   cloudinaryService,
+		Upload:       uploadService,
+		Promo:        NewPromoService(db, cfg),
+		Review:       NewReviewService(db, cfg),
+		Search:       NewSearchService(db, cfg),
+		Session:      NewSessionService(db, cfg),
+		SMS:          NewSMSService(db, cfg),
+		Lockout:      NewLockoutService(db, cfg),
+		RBAC:         NewRBACService(db, cfg),
+		Staff:        NewStaffService(db, cfg),
+		MFA:          NewMFAService(db, cfg),
+		Token:        NewTokenService(db, cfg),
+		Notification: NewNotificationService(db, cfg),
+		Push:         NewPushService(db, cfg),
+		Outbox:       NewOutboxService(db, cfg),
+		Webhook:      NewWebhookService(db, cfg),
+		APIKey:       NewAPIKeyService(db, cfg),
 	}
 }
-
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"kenyan-food-delivery/internal/auth"
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// refreshTokenLifetime is how long each rotated refresh token stays valid
+const refreshTokenLifetime = 7 * 24 * time.Hour
+
+// SessionService manages server-side login sessions and refresh token rotation
+type SessionService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(db *gorm.DB, cfg *config.Config) *SessionService {
+	return &SessionService{
+		db:     db,
+		config: cfg,
+	}
+}
+
+// DeviceInfo describes the client a session was created from
+type DeviceInfo struct {
+	DeviceName string
+	UserAgent  string
+	IPAddress  string
+}
+
+// IssuedSession holds a newly stored session and its signed refresh token
+type IssuedSession struct {
+	Session      *models.Session
+	RefreshToken string
+}
+
+// CreateSession starts a new session family for a user
+func (s *SessionService) CreateSession(user *models.User, device *DeviceInfo) (*IssuedSession, error) {
+	familyID, err := auth.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(s.db, user, familyID, device)
+}
+
+// Rotate exchanges a refresh token for a new one in the same family.
+// Presenting a token that was already exchanged revokes the whole family.
+func (s *SessionService) Rotate(refreshToken string, device *DeviceInfo) (*models.User, *IssuedSession, error) {
+	claims, err := auth.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, nil, errors.New("invalid refresh token")
+	}
+
+	var user models.User
+	var issued *IssuedSession
+	reused := false
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var session models.Session
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND family_id = ? AND user_id = ?", claims.ID, claims.SessionID, claims.UserID).
+			First(&session).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.New("invalid refresh token")
+			}
+			return err
+		}
+
+		if session.RevokedAt != nil {
+			return errors.New("session has been revoked")
+		}
+
+		if session.RotatedAt != nil {
+			reused = true
+			return nil
+		}
+
+		if time.Now().After(session.ExpiresAt) {
+			return errors.New("refresh token has expired")
+		}
+
+		if err := tx.First(&user, session.UserID).Error; err != nil {
+			return errors.New("user not found")
+		}
+		if user.Status != models.StatusActive {
+			return errors.New("account is not active")
+		}
+
+		now := time.Now()
+		if err := tx.Model(&session).Update("rotated_at", now).Error; err != nil {
+			return err
+		}
+
+		if device == nil {
+			device = &DeviceInfo{}
+		}
+		if device.DeviceName == "" {
+			device.DeviceName = session.DeviceName
+		}
+
+		issued, err = s.issue(tx, &user, session.FamilyID, device)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if reused {
+		// A stolen token was replayed, or the legitimate client was; either way nobody keeps the session
+		if err := s.revokeFamily(s.db, claims.SessionID, models.SessionRevokedTokenReuse); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("refresh token reuse detected, session revoked")
+	}
+
+	return &user, issued, nil
+}
+
+// ValidateSession reports an error when a session family is revoked or expired
+func (s *SessionService) ValidateSession(familyID string) error {
+	var count int64
+	if err := s.db.Model(&models.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL AND rotated_at IS NULL AND expires_at > ?", familyID, time.Now()).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("session is no longer active")
+	}
+	return nil
+}
+
+// GetUserSessions lists a user's active sessions, one entry per login
+func (s *SessionService) GetUserSessions(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	if err := s.db.Where("user_id = ? AND revoked_at IS NULL AND rotated_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends one of a user's sessions
+func (s *SessionService) RevokeSession(userID uint, familyID, reason string) error {
+	result := s.db.Model(&models.Session{}).
+		Where("family_id = ? AND user_id = ? AND revoked_at IS NULL", familyID, userID).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("session not found")
+	}
+	return nil
+}
+
+// RevokeAllSessions ends every session a user has, returning how many logins were ended
+func (s *SessionService) RevokeAllSessions(userID uint, reason string) (int64, error) {
+	return revokeUserSessions(s.db, userID, reason)
+}
+
+// issue stores a session row and signs its refresh token
+func (s *SessionService) issue(tx *gorm.DB, user *models.User, familyID string, device *DeviceInfo) (*IssuedSession, error) {
+	tokenID, err := auth.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:         tokenID,
+		FamilyID:   familyID,
+		UserID:     user.ID,
+		ExpiresAt:  now.Add(refreshTokenLifetime),
+		LastUsedAt: now,
+	}
+	if device != nil {
+		session.DeviceName = truncate(device.DeviceName, 100)
+		session.UserAgent = truncate(device.UserAgent, 255)
+		session.IPAddress = device.IPAddress
+	}
+
+	if err := tx.Create(session).Error; err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := auth.GenerateRefreshToken(user, familyID, tokenID, session.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IssuedSession{Session: session, RefreshToken: refreshToken}, nil
+}
+
+// revokeFamily revokes every token in a session family
+func (s *SessionService) revokeFamily(tx *gorm.DB, familyID, reason string) error {
+	return tx.Model(&models.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		}).Error
+}
+
+// revokeUserSessions revokes all of a user's sessions, for use inside other services' transactions
+func revokeUserSessions(tx *gorm.DB, userID uint, reason string) (int64, error) {
+	var families int64
+	if err := tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL AND rotated_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Count(&families).Error; err != nil {
+		return 0, err
+	}
+
+	if err := tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		}).Error; err != nil {
+		return 0, err
+	}
+
+	return families, nil
+}
+
+// truncate shortens a string to at most n characters without splitting a multi-byte character
+func truncate(value string, n int) string {
+	count := 0
+	for i := range value {
+		if count == n {
+			return value[:i]
+		}
+		count++
+	}
+	return value
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+	"kenyan-food-delivery/pkg/africastalking"
+	"kenyan-food-delivery/pkg/location"
+
+	"gorm.io/gorm"
+)
+
+// SMS message categories, used for cost reporting
+const (
+	SMSCategoryOTP          = "otp"
+	SMSCategoryOrderUpdate  = "order_update"
+	SMSCategoryStaffInvite  = "staff_invite"
+	SMSCategorySecurity     = "security"
+	SMSCategoryNotification = "notification"
+)
+
+// SMSResult is a provider's response for a single message
+type SMSResult struct {
+	MessageID      string
+	Status         models.SMSStatus
+	ProviderStatus string
+	Currency       string
+	Cost           float64
+}
+
+// SMSSender delivers a text message to a single phone number
+type SMSSender interface {
+	Name() string
+	Send(to, message string) (*SMSResult, error)
+}
+
+// SMSService sends text messages through the configured provider and logs each one
+type SMSService struct {
+	db     *gorm.DB
+	config *config.Config
+	sender SMSSender
+}
+
+// NewSMSService creates a new SMS service using the provider named in the config
+func NewSMSService(db *gorm.DB, cfg *config.Config) *SMSService {
+	var sender SMSSender
+	switch cfg.SMSProvider {
+	case "africastalking":
+		sender = NewAfricasTalkingSender(africastalking.NewClient(
+			cfg.AfricasTalkingUsername,
+			cfg.AfricasTalkingAPIKey,
+			cfg.AfricasTalkingEnvironment,
+			cfg.SMSSenderID,
+		))
+	default:
+		if cfg.Environment == "production" {
+			// Config.Validate stops the server starting like this; refuse rather than pretend to send
+			sender = unconfiguredSMSSender{}
+		} else {
+			sender = NewFakeSMSSender(true)
+		}
+	}
+
+	return &SMSService{
+		db:     db,
+		config: cfg,
+		sender: sender,
+	}
+}
+
+// Sender returns the configured provider, e.g. to read a FakeSMSSender's messages in development
+func (s *SMSService) Sender() SMSSender {
+	return s.sender
+}
+
+// Send delivers a message to a Kenyan mobile number and records its cost and status
+func (s *SMSService) Send(to, message, category string) error {
+	phoneNumber, err := location.NormalizePhoneNumber(to)
+	if err != nil {
+		return err
+	}
+
+	record := &models.SMSMessage{
+		Provider:    s.sender.Name(),
+		PhoneNumber: phoneNumber,
+		Category:    category,
+	}
+
+	result, sendErr := s.sender.Send(phoneNumber, message)
+	if sendErr != nil {
+		record.Status = models.SMSStatusFailed
+		record.FailureReason = sendErr.Error()
+	} else {
+		record.ProviderMessageID = result.MessageID
+		record.Status = result.Status
+		record.ProviderStatus = result.ProviderStatus
+		record.Currency = result.Currency
+		record.Cost = result.Cost
+		if result.Status == models.SMSStatusDelivered {
+			now := time.Now()
+			record.DeliveredAt = &now
+		}
+		log.Printf("SMS %s to %s via %s: status=%s cost=%s %.4f",
+			category, phoneNumber, record.Provider, record.Status, record.Currency, record.Cost)
+	}
+
+	if err := s.db.Create(record).Error; err != nil {
+		log.Printf("Failed to record SMS to %s: %v", phoneNumber, err)
+	}
+
+	return sendErr
+}
+
+// HandleDeliveryReport updates a logged message from a provider delivery report
+func (s *SMSService) HandleDeliveryReport(report *africastalking.DeliveryReport) error {
+	if report.ID == "" {
+		return errors.New("message id is required")
+	}
+
+	var record models.SMSMessage
+	if err := s.db.Where("provider_message_id = ?", report.ID).First(&record).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("message not found")
+		}
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"provider_status": report.Status,
+		"failure_reason":  report.FailureReason,
+		"network_code":    report.NetworkCode,
+	}
+	switch report.Status {
+	case africastalking.StatusSuccess:
+		updates["status"] = models.SMSStatusDelivered
+		updates["delivered_at"] = time.Now()
+	case africastalking.StatusFailed, africastalking.StatusRejected:
+		updates["status"] = models.SMSStatusFailed
+	default:
+		updates["status"] = models.SMSStatusSent
+	}
+
+	return s.db.Model(&record).Updates(updates).Error
+}
+
+// AfricasTalkingSender sends messages through Africa's Talking bulk SMS
+type AfricasTalkingSender struct {
+	client *africastalking.Client
+}
+
+// NewAfricasTalkingSender creates a sender backed by an Africa's Talking client
+func NewAfricasTalkingSender(client *africastalking.Client) *AfricasTalkingSender {
+	return &AfricasTalkingSender{client: client}
+}
+
+// Name identifies the provider in the message log
+func (a *AfricasTalkingSender) Name() string {
+	return "africastalking"
+}
+
+// Send submits a message and reports its cost
+func (a *AfricasTalkingSender) Send(to, message string) (*SMSResult, error) {
+	resp, err := a.client.SendSMS([]string{to}, message)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.SMSMessageData.Recipients) == 0 {
+		return nil, fmt.Errorf("SMS not sent: %s", resp.SMSMessageData.Message)
+	}
+
+	recipient := resp.SMSMessageData.Recipients[0]
+	// 100 Processed, 101 Sent and 102 Queued are accepted; every other code is a rejection
+	if recipient.StatusCode < 100 || recipient.StatusCode > 102 {
+		return nil, fmt.Errorf("SMS rejected: %s", recipient.Status)
+	}
+
+	currency, cost := africastalking.ParseCost(recipient.Cost)
+	return &SMSResult{
+		MessageID:      recipient.MessageID,
+		Status:         models.SMSStatusSent,
+		ProviderStatus: recipient.Status,
+		Currency:       currency,
+		Cost:           cost,
+	}, nil
+}
+
+// unconfiguredSMSSender fails every message, for production without a real provider
+type unconfiguredSMSSender struct{}
+
+// Name identifies the provider in the message log
+func (unconfiguredSMSSender) Name() string {
+	return "none"
+}
+
+// Send refuses to send
+func (unconfiguredSMSSender) Send(to, message string) (*SMSResult, error) {
+	return nil, errors.New("no SMS provider is configured")
+}
+
+// maxFakeSMSMessages bounds the messages a FakeSMSSender keeps, oldest dropped first
+const maxFakeSMSMessages = 500
+
+// FakeSMS is a message captured by FakeSMSSender
+type FakeSMS struct {
+	To      string    `json:"to"`
+	Message string    `json:"message"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// FakeSMSSender records messages in memory instead of sending them, for tests and local development.
+// It is never used in production.
+type FakeSMSSender struct {
+	mu          sync.Mutex
+	messages    []FakeSMS // The most recent maxFakeSMSMessages
+	sent        int
+	logMessages bool
+}
+
+// NewFakeSMSSender creates a fake sender; logMessages also prints each message to the log
+func NewFakeSMSSender(logMessages bool) *FakeSMSSender {
+	return &FakeSMSSender{logMessages: logMessages}
+}
+
+// Name identifies the provider in the message log
+func (f *FakeSMSSender) Name() string {
+	return "fake"
+}
+
+// Send records the message as delivered
+func (f *FakeSMSSender) Send(to, message string) (*SMSResult, error) {
+	f.mu.Lock()
+	f.sent++
+	id := f.sent
+	f.messages = append(f.messages, FakeSMS{To: to, Message: message, SentAt: time.Now()})
+	if len(f.messages) > maxFakeSMSMessages {
+		f.messages = f.messages[len(f.messages)-maxFakeSMSMessages:]
+	}
+	f.mu.Unlock()
+
+	if f.logMessages {
+		log.Printf("SMS to %s: %s", to, message)
+	}
+
+	return &SMSResult{
+		MessageID:      fmt.Sprintf("fake-%d", id),
+		Status:         models.SMSStatusDelivered,
+		ProviderStatus: "Success",
+		Currency:       "KES",
+	}, nil
+}
+
+// Messages returns a copy of the recorded messages
+func (f *FakeSMSSender) Messages() []FakeSMS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeSMS(nil), f.messages...)
+}
+
+// Reset clears the recorded messages
+func (f *FakeSMSSender) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages = nil
+}
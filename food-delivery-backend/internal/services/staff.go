@@ -0,0 +1,379 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+	"kenyan-food-delivery/internal/templates"
+	"kenyan-food-delivery/pkg/location"
+
+	"gorm.io/gorm"
+)
+
+// staffInviteLifetime is how long an invitation code can be accepted
+const staffInviteLifetime = 7 * 24 * time.Hour
+
+// inviteCodeAlphabet leaves out characters that are easily confused when typed from an SMS
+const inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// StaffService manages restaurant staff invitations and memberships
+type StaffService struct {
+	db     *gorm.DB
+	config *config.Config
+	email  *EmailService
+	outbox *OutboxService
+}
+
+// NewStaffService creates a new staff service
+func NewStaffService(db *gorm.DB, cfg *config.Config) *StaffService {
+	return &StaffService{
+		db:     db,
+		config: cfg,
+		email:  NewEmailService(db, cfg),
+		outbox: NewOutboxService(db, cfg),
+	}
+}
+
+// InviteStaffRequest invites someone to a restaurant by email, phone number or both
+type InviteStaffRequest struct {
+	Email       string           `json:"email" binding:"omitempty,email"`
+	PhoneNumber string           `json:"phone_number"`
+	Role        models.StaffRole `json:"role" binding:"required,oneof=manager cashier kitchen"`
+}
+
+// UpdateStaffRequest changes a staff member's role
+type UpdateStaffRequest struct {
+	Role models.StaffRole `json:"role" binding:"required,oneof=manager cashier kitchen"`
+}
+
+// AcceptInvitationRequest carries the code from an invitation email or SMS
+type AcceptInvitationRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// InviteStaff creates an invitation and sends its code by email and/or SMS
+func (s *StaffService) InviteStaff(inviterID, restaurantID uint, req *InviteStaffRequest) (*models.RestaurantStaff, error) {
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	var phoneNumber string
+	if strings.TrimSpace(req.PhoneNumber) != "" {
+		normalized, err := location.NormalizePhoneNumber(req.PhoneNumber)
+		if err != nil {
+			return nil, err
+		}
+		phoneNumber = normalized
+	}
+	if email == "" && phoneNumber == "" {
+		return nil, errors.New("email or phone number is required")
+	}
+
+	var restaurant models.Restaurant
+	if err := s.db.Select("id", "name", "owner_id").First(&restaurant, restaurantID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("restaurant not found")
+		}
+		return nil, err
+	}
+
+	// Refuse to invite the owner, or anyone already invited or on staff
+	var owner models.User
+	if err := s.db.Select("id", "email", "phone_number").First(&owner, restaurant.OwnerID).Error; err == nil {
+		ownerPhone, _ := location.NormalizePhoneNumber(owner.PhoneNumber)
+		if (email != "" && strings.EqualFold(owner.Email, email)) || (phoneNumber != "" && ownerPhone == phoneNumber) {
+			return nil, errors.New("the restaurant owner cannot be invited as staff")
+		}
+	}
+
+	var existing int64
+	query := s.db.Model(&models.RestaurantStaff{}).
+		Where("restaurant_id = ? AND status <> ?", restaurantID, models.StaffStatusRevoked)
+	switch {
+	case email != "" && phoneNumber != "":
+		query = query.Where("(invited_email = ? OR invited_phone = ?)", email, phoneNumber)
+	case email != "":
+		query = query.Where("invited_email = ?", email)
+	default:
+		query = query.Where("invited_phone = ?", phoneNumber)
+	}
+	if err := query.Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, errors.New("this person has already been invited or is already on staff")
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(staffInviteLifetime)
+	staff := &models.RestaurantStaff{
+		RestaurantID:    restaurantID,
+		Role:            req.Role,
+		Status:          models.StaffStatusInvited,
+		InvitedEmail:    email,
+		InvitedPhone:    phoneNumber,
+		InviteCodeHash:  hashInviteCode(code),
+		InviteExpiresAt: &expiresAt,
+		InvitedBy:       inviterID,
+	}
+	if err := s.db.Create(staff).Error; err != nil {
+		return nil, err
+	}
+
+	// Invitees who already have an account get the invitation in their own language
+	language := s.inviteeLanguage(email, phoneNumber)
+	if email != "" {
+		if err := s.email.SendStaffInvitationEmail(email, language, restaurant.Name, string(req.Role), code, expiresAt); err != nil {
+			log.Printf("Failed to send staff invitation email to %s: %v", email, err)
+		}
+	}
+	if phoneNumber != "" {
+		message, err := templates.RenderSMS(language, "staff_invitation", map[string]interface{}{
+			"RestaurantName": restaurant.Name,
+			"Role":           req.Role,
+			"Code":           code,
+		})
+		if err == nil {
+			err = s.outbox.EnqueueSMS(phoneNumber, message, SMSCategoryStaffInvite, true)
+		}
+		if err != nil {
+			log.Printf("Failed to send staff invitation SMS to %s: %v", phoneNumber, err)
+		}
+	}
+
+	return staff, nil
+}
+
+// inviteeLanguage returns the preferred language of the account matching an invitation, if any
+func (s *StaffService) inviteeLanguage(email, phoneNumber string) string {
+	var user models.User
+	query := s.db.Select("preferred_language")
+	switch {
+	case email != "" && phoneNumber != "":
+		query = query.Where("email = ? OR phone_number = ?", email, phoneNumber)
+	case email != "":
+		query = query.Where("email = ?", email)
+	default:
+		query = query.Where("phone_number = ?", phoneNumber)
+	}
+	if err := query.First(&user).Error; err != nil {
+		return templates.DefaultLocale
+	}
+	return user.PreferredLanguage
+}
+
+// GetRestaurantStaff lists a restaurant's staff and outstanding invitations
+func (s *StaffService) GetRestaurantStaff(restaurantID uint) ([]models.RestaurantStaff, error) {
+	var staff []models.RestaurantStaff
+	if err := s.db.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "first_name", "last_name", "email", "phone_number")
+	}).Where("restaurant_id = ? AND status <> ?", restaurantID, models.StaffStatusRevoked).
+		Order("created_at DESC").Find(&staff).Error; err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+// UpdateStaffRole changes a staff member's role at a restaurant
+func (s *StaffService) UpdateStaffRole(restaurantID, staffID uint, req *UpdateStaffRequest) (*models.RestaurantStaff, error) {
+	staff, err := s.getStaff(restaurantID, staffID)
+	if err != nil {
+		return nil, err
+	}
+
+	staff.Role = req.Role
+	if err := s.db.Model(staff).Update("role", req.Role).Error; err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+// RevokeStaff removes a staff member's access, or cancels an outstanding invitation
+func (s *StaffService) RevokeStaff(actorID, restaurantID, staffID uint) error {
+	staff, err := s.getStaff(restaurantID, staffID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(staff).Updates(map[string]interface{}{
+			"status":           models.StaffStatusRevoked,
+			"revoked_at":       now,
+			"revoked_by":       actorID,
+			"invite_code_hash": "",
+		}).Error; err != nil {
+			return err
+		}
+
+		if staff.UserID != nil {
+			return syncStaffRole(tx, *staff.UserID)
+		}
+		return nil
+	})
+}
+
+// GetMyInvitations lists outstanding invitations sent to the user's email or phone number
+func (s *StaffService) GetMyInvitations(userID uint) ([]models.RestaurantStaff, error) {
+	var user models.User
+	if err := s.db.Select("id", "email", "phone_number").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	// Invitations store the phone number normalized; an unparseable number matches nothing
+	userPhone, _ := location.NormalizePhoneNumber(user.PhoneNumber)
+
+	var invitations []models.RestaurantStaff
+	if err := s.db.Preload("Restaurant", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "name", "logo", "county")
+	}).Where("status = ? AND invite_expires_at > ? AND (invited_email = ? OR (invited_phone <> '' AND invited_phone = ?))",
+		models.StaffStatusInvited, time.Now(), strings.ToLower(user.Email), userPhone).
+		Order("created_at DESC").Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// AcceptInvitation joins the user to a restaurant's staff. The invitation must have been
+// sent to the user's own email or phone number.
+func (s *StaffService) AcceptInvitation(userID uint, req *AcceptInvitationRequest) (*models.RestaurantStaff, error) {
+	var user models.User
+	if err := s.db.Select("id", "email", "phone_number").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	var staff models.RestaurantStaff
+	if err := s.db.Where("invite_code_hash = ? AND status = ?", hashInviteCode(code), models.StaffStatusInvited).
+		First(&staff).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("invalid or expired invitation code")
+		}
+		return nil, err
+	}
+	if staff.InviteExpiresAt == nil || time.Now().After(*staff.InviteExpiresAt) {
+		return nil, errors.New("invalid or expired invitation code")
+	}
+
+	userPhone, _ := location.NormalizePhoneNumber(user.PhoneNumber)
+	if !(staff.InvitedEmail != "" && strings.EqualFold(staff.InvitedEmail, user.Email)) &&
+		!(staff.InvitedPhone != "" && staff.InvitedPhone == userPhone) {
+		return nil, errors.New("this invitation was sent to a different email or phone number")
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var active int64
+		if err := tx.Model(&models.RestaurantStaff{}).
+			Where("restaurant_id = ? AND user_id = ? AND status = ?", staff.RestaurantID, userID, models.StaffStatusActive).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return errors.New("you are already on this restaurant's staff")
+		}
+
+		now := time.Now()
+		staff.UserID = &userID
+		staff.Status = models.StaffStatusActive
+		staff.AcceptedAt = &now
+		if err := tx.Model(&staff).Updates(map[string]interface{}{
+			"user_id":          userID,
+			"status":           models.StaffStatusActive,
+			"accepted_at":      now,
+			"invite_code_hash": "",
+		}).Error; err != nil {
+			return err
+		}
+
+		return syncStaffRole(tx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &staff, nil
+}
+
+// GetMyRestaurants lists the restaurants where the user is active staff
+func (s *StaffService) GetMyRestaurants(userID uint) ([]models.RestaurantStaff, error) {
+	var memberships []models.RestaurantStaff
+	if err := s.db.Preload("Restaurant", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "name", "logo", "county", "is_open")
+	}).Where("user_id = ? AND status = ?", userID, models.StaffStatusActive).
+		Order("accepted_at DESC").Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// getStaff loads a non-revoked staff record belonging to a restaurant
+func (s *StaffService) getStaff(restaurantID, staffID uint) (*models.RestaurantStaff, error) {
+	var staff models.RestaurantStaff
+	if err := s.db.Where("id = ? AND restaurant_id = ? AND status <> ?", staffID, restaurantID, models.StaffStatusRevoked).
+		First(&staff).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("staff member not found")
+		}
+		return nil, err
+	}
+	return &staff, nil
+}
+
+// syncStaffRole gives the user the restaurant_staff role while they have an active
+// membership anywhere, and takes it away once they have none. Assignments made by a
+// super admin are left alone.
+func syncStaffRole(tx *gorm.DB, userID uint) error {
+	var role models.Role
+	if err := tx.Where("name = ?", string(models.RoleRestaurantStaff)).First(&role).Error; err != nil {
+		return err
+	}
+
+	var active int64
+	if err := tx.Model(&models.RestaurantStaff{}).
+		Where("user_id = ? AND status = ?", userID, models.StaffStatusActive).
+		Count(&active).Error; err != nil {
+		return err
+	}
+
+	var assigned int64
+	if err := tx.Model(&models.UserRoleAssignment{}).
+		Where("user_id = ? AND role_id = ?", userID, role.ID).
+		Count(&assigned).Error; err != nil {
+		return err
+	}
+
+	switch {
+	case active > 0 && assigned == 0:
+		return tx.Create(&models.UserRoleAssignment{UserID: userID, RoleID: role.ID}).Error
+	case active == 0 && assigned > 0:
+		return tx.Where("user_id = ? AND role_id = ? AND assigned_by IS NULL", userID, role.ID).
+			Delete(&models.UserRoleAssignment{}).Error
+	}
+	return nil
+}
+
+// generateInviteCode creates an 8 character invitation code
+func generateInviteCode() (string, error) {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	for i, b := range bytes {
+		bytes[i] = inviteCodeAlphabet[int(b)%len(inviteCodeAlphabet)]
+	}
+	return string(bytes), nil
+}
+
+// hashInviteCode hashes an invitation code for storage
+func hashInviteCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"kenyan-food-delivery/internal/auth"
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tokenLifetimes is how long each kind of emailed token stays valid
+var tokenLifetimes = map[models.TokenPurpose]time.Duration{
+	models.TokenPurposeEmailVerification: 24 * time.Hour,
+	models.TokenPurposePasswordReset:     time.Hour,
+}
+
+// tokenRetention is how long used and expired tokens are kept for investigating abuse
+const tokenRetention = 7 * 24 * time.Hour
+
+// Errors returned when a token can't be used
+var (
+	errTokenInvalid  = errors.New("invalid or unknown token")
+	errTokenExpired  = errors.New("token has expired, please request a new one")
+	errTokenConsumed = errors.New("token has already been used")
+)
+
+// TokenService issues and redeems single-use tokens sent by email
+type TokenService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewTokenService creates a new token service
+func NewTokenService(db *gorm.DB, cfg *config.Config) *TokenService {
+	return &TokenService{
+		db:     db,
+		config: cfg,
+	}
+}
+
+// Issue creates a token for a user and returns it; only its hash is stored.
+// Earlier unused tokens for the same purpose stay valid until one of them is used.
+func (s *TokenService) Issue(db *gorm.DB, userID uint, purpose models.TokenPurpose, requestIP string) (string, error) {
+	raw, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	token := models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(raw),
+		RequestIP: requestIP,
+		ExpiresAt: time.Now().Add(tokenLifetimes[purpose]),
+	}
+	if err := db.Create(&token).Error; err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// Consume redeems a token inside tx, also invalidating the user's other unused tokens for the
+// same purpose. If tx is rolled back the token remains usable.
+func (s *TokenService) Consume(tx *gorm.DB, raw string, purpose models.TokenPurpose) (*models.UserToken, error) {
+	var token models.UserToken
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ? AND purpose = ?", hashToken(raw), purpose).
+		First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errTokenInvalid
+		}
+		return nil, err
+	}
+
+	if token.ConsumedAt != nil {
+		return nil, errTokenConsumed
+	}
+	now := time.Now()
+	if now.After(token.ExpiresAt) {
+		return nil, errTokenExpired
+	}
+
+	if err := tx.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND consumed_at IS NULL", token.UserID, purpose).
+		Update("consumed_at", now).Error; err != nil {
+		return nil, err
+	}
+
+	token.ConsumedAt = &now
+	return &token, nil
+}
+
+// CleanupExpired deletes tokens that were used or expired longer ago than the retention period
+func (s *TokenService) CleanupExpired() (int64, error) {
+	cutoff := time.Now().Add(-tokenRetention)
+	result := s.db.Where("expires_at < ? OR consumed_at < ?", cutoff, cutoff).Delete(&models.UserToken{})
+	return result.RowsAffected, result.Error
+}
+
+// RunCleanup calls CleanupExpired every interval; it never returns
+func (s *TokenService) RunCleanup(interval time.Duration) {
+	runPeriodically(interval, "expired tokens", s.CleanupExpired)
+}
+
+// hashToken hashes an emailed token for storage and lookup
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
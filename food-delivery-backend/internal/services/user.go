@@ -147,3 +147,39 @@ func (s *UserService) GetUserByID(userID uint) (*models.User, error) {
 	return &user, nil
 }
 
+
+// UpdateUserStatusRequest represents an admin change to an account's status
+type UpdateUserStatusRequest struct {
+	Status models.UserStatus `json:"status" binding:"required,oneof=active inactive suspended"`
+}
+
+// UpdateUserStatus changes an account's status; leaving active revokes every session
+func (s *UserService) UpdateUserStatus(userID uint, req *UpdateUserStatusRequest) (*models.User, error) {
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, userID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.New("user not found")
+			}
+			return err
+		}
+
+		user.Status = req.Status
+		if err := tx.Model(&user).Update("status", req.Status).Error; err != nil {
+			return err
+		}
+
+		if req.Status != models.StatusActive {
+			if _, err := revokeUserSessions(tx, user.ID, models.SessionRevokedAccountStatus); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user.Password = ""
+	return &user, nil
+}
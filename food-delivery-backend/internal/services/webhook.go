@@ -0,0 +1,611 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"kenyan-food-delivery/internal/auth"
+	"kenyan-food-delivery/internal/config"
+	"kenyan-food-delivery/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Webhook delivery rules
+const (
+	webhookBatchSize         = 20
+	webhookBaseBackoff       = time.Minute // Doubled after each failed attempt
+	webhookMaxBackoff        = 6 * time.Hour
+	webhookClaimTimeout      = 10 * time.Minute
+	webhookResponseLimit     = 1024 // Bytes of the partner's response kept in the log
+	webhookDeliveryRetention = 30 * 24 * time.Hour
+)
+
+// Headers sent with every webhook delivery. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the subscription secret, prefixed with "sha256=".
+const (
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderEventID   = "X-Webhook-Event-Id"
+	WebhookHeaderDelivery  = "X-Webhook-Delivery"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+// webhookWake lets the worker send a new delivery straight away instead of at its next poll
+var webhookWake = make(chan struct{}, 1)
+
+// WebhookService manages restaurants' webhook subscriptions and delivers their events
+type WebhookService struct {
+	db     *gorm.DB
+	config *config.Config
+	client *http.Client
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(db *gorm.DB, cfg *config.Config) *WebhookService {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if cfg.Environment == "production" {
+		dialer.Control = rejectInternalAddress
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+
+	return &WebhookService{
+		db:     db,
+		config: cfg,
+		client: &http.Client{
+			Timeout:   time.Duration(cfg.WebhookTimeout) * time.Second,
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse // A redirect is reported as a failed delivery
+			},
+		},
+	}
+}
+
+// rejectInternalAddress stops webhooks reaching the platform's own network
+func rejectInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+		return fmt.Errorf("webhook address %s is not allowed", host)
+	}
+	return nil
+}
+
+// CreateWebhookRequest subscribes a URL to a restaurant's events
+type CreateWebhookRequest struct {
+	URL         string                `json:"url" binding:"required,url,max=2048"`
+	Events      []models.WebhookEvent `json:"events" binding:"required,min=1,dive,oneof=order.created order.status_changed"`
+	Secret      string                `json:"secret" binding:"omitempty,min=16,max=128"` // Generated when empty
+	Description string                `json:"description" binding:"max=255"`
+}
+
+// UpdateWebhookRequest changes a subscription; omitted fields are left as they are
+type UpdateWebhookRequest struct {
+	URL         *string               `json:"url" binding:"omitempty,url,max=2048"`
+	Events      []models.WebhookEvent `json:"events" binding:"omitempty,min=1,dive,oneof=order.created order.status_changed"`
+	Description *string               `json:"description" binding:"omitempty,max=255"`
+	IsActive    *bool                 `json:"is_active"`
+}
+
+// CreatedWebhook is a new subscription with its signing secret, which is not shown again
+type CreatedWebhook struct {
+	*models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// WebhookDeliveryQuery selects a page of a restaurant's delivery log, newest first
+type WebhookDeliveryQuery struct {
+	SubscriptionID uint                         `form:"webhook_id"`
+	Event          models.WebhookEvent          `form:"event"`
+	Status         models.WebhookDeliveryStatus `form:"status"`
+	Page           int                          `form:"page"`
+	Limit          int                          `form:"limit"`
+}
+
+// GetSubscriptions lists a restaurant's webhook subscriptions
+func (s *WebhookService) GetSubscriptions(restaurantID uint) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := s.db.Where("restaurant_id = ?", restaurantID).Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// CreateSubscription subscribes a partner URL to a restaurant's events
+func (s *WebhookService) CreateSubscription(actorID, restaurantID uint, req *CreateWebhookRequest) (*CreatedWebhook, error) {
+	if err := s.validateURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		token, err := auth.GenerateRandomToken(24)
+		if err != nil {
+			return nil, err
+		}
+		secret = "whsec_" + token
+	}
+
+	subscription := &models.WebhookSubscription{
+		RestaurantID: restaurantID,
+		URL:          req.URL,
+		Secret:       secret,
+		Events:       uniqueWebhookEvents(req.Events),
+		Description:  req.Description,
+		IsActive:     true,
+		CreatedBy:    actorID,
+	}
+	if err := s.db.Create(subscription).Error; err != nil {
+		return nil, err
+	}
+
+	return &CreatedWebhook{WebhookSubscription: subscription, Secret: secret}, nil
+}
+
+// UpdateSubscription changes a subscription's URL, events, description or whether it is active
+func (s *WebhookService) UpdateSubscription(restaurantID, subscriptionID uint, req *UpdateWebhookRequest) (*models.WebhookSubscription, error) {
+	subscription, err := s.getSubscription(restaurantID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := s.validateURL(*req.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = *req.URL
+	}
+	if req.Events != nil {
+		subscription.Events = uniqueWebhookEvents(req.Events)
+	}
+	if req.Description != nil {
+		subscription.Description = *req.Description
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+
+	if err := s.db.Save(subscription).Error; err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// DeleteSubscription removes a subscription; its delivery log is kept until it expires
+func (s *WebhookService) DeleteSubscription(restaurantID, subscriptionID uint) error {
+	subscription, err := s.getSubscription(restaurantID, subscriptionID)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(subscription).Error
+}
+
+// GetDeliveries returns a page of a restaurant's delivery log, newest first
+func (s *WebhookService) GetDeliveries(restaurantID uint, query *WebhookDeliveryQuery) ([]models.WebhookDelivery, int64, error) {
+	var deliveries []models.WebhookDelivery
+	var total int64
+
+	db := s.db.Model(&models.WebhookDelivery{}).Where("restaurant_id = ?", restaurantID)
+	if query.SubscriptionID != 0 {
+		db = db.Where("subscription_id = ?", query.SubscriptionID)
+	}
+	if query.Event != "" {
+		db = db.Where("event = ?", query.Event)
+	}
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (query.Page - 1) * query.Limit
+	if err := db.Offset(offset).Limit(query.Limit).Order("id DESC").Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+// Redeliver sends a logged event to its subscription again as a new delivery with the same event ID
+func (s *WebhookService) Redeliver(restaurantID, deliveryID uint) (*models.WebhookDelivery, error) {
+	var original models.WebhookDelivery
+	if err := s.db.Where("id = ? AND restaurant_id = ?", deliveryID, restaurantID).First(&original).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("delivery not found")
+		}
+		return nil, err
+	}
+
+	subscription, err := s.getSubscription(restaurantID, original.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !subscription.IsActive {
+		return nil, errors.New("webhook is disabled")
+	}
+
+	delivery := &models.WebhookDelivery{
+		SubscriptionID: subscription.ID,
+		RestaurantID:   restaurantID,
+		EventID:        original.EventID,
+		Event:          original.Event,
+		Payload:        original.Payload,
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+		RedeliveryOf:   &original.ID,
+	}
+	if err := s.db.Create(delivery).Error; err != nil {
+		return nil, err
+	}
+
+	wakeWebhooks()
+	return delivery, nil
+}
+
+// PublishOrderCreated sends order.created to the restaurant's subscribers
+func (s *WebhookService) PublishOrderCreated(order *models.Order) {
+	s.publishOrder(models.WebhookEventOrderCreated, order.ID, nil)
+}
+
+// PublishOrderStatusChanged sends order.status_changed to the restaurant's subscribers
+func (s *WebhookService) PublishOrderStatusChanged(order *models.Order, from models.OrderStatus) {
+	s.publishOrder(models.WebhookEventOrderStatusChanged, order.ID, map[string]interface{}{"previous_status": from})
+}
+
+// PublishPaymentCompleted sends payment.completed to the subscribers of the order's restaurant
+func (s *WebhookService) PublishPaymentCompleted(payment *models.Payment) {
+	var order models.Order
+	if err := s.db.Select("id", "restaurant_id", "order_number").First(&order, payment.OrderID).Error; err != nil {
+		log.Printf("Failed to load order %d for payment webhook: %v", payment.OrderID, err)
+		return
+	}
+
+	s.publish(order.RestaurantID, models.WebhookEventPaymentCompleted, map[string]interface{}{
+		"payment_id":     payment.ID,
+		"order_id":       order.ID,
+		"order_number":   order.OrderNumber,
+		"amount":         payment.Amount,
+		"currency":       payment.Currency,
+		"method":         payment.Method,
+		"receipt_number": payment.MpesaReceiptNumber,
+		"processed_at":   payment.ProcessedAt,
+	})
+}
+
+// publishOrder sends an order event with the order's current state and any extra fields
+func (s *WebhookService) publishOrder(event models.WebhookEvent, orderID uint, extra map[string]interface{}) {
+	var order models.Order
+	if err := s.db.Preload("OrderItems.MenuItem", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "name")
+	}).First(&order, orderID).Error; err != nil {
+		log.Printf("Failed to load order %d for %s webhook: %v", orderID, event, err)
+		return
+	}
+
+	items := make([]map[string]interface{}, len(order.OrderItems))
+	for i, item := range order.OrderItems {
+		items[i] = map[string]interface{}{
+			"menu_item_id":    item.MenuItemID,
+			"name":            item.MenuItem.Name,
+			"quantity":        item.Quantity,
+			"unit_price":      item.UnitPrice,
+			"total_price":     item.TotalPrice,
+			"special_request": item.SpecialRequest,
+		}
+	}
+
+	data := map[string]interface{}{
+		"order_id":             order.ID,
+		"order_number":         order.OrderNumber,
+		"status":               order.Status,
+		"sub_total":            order.SubTotal,
+		"delivery_fee":         order.DeliveryFee,
+		"discount_amount":      order.DiscountAmount,
+		"total_amount":         order.TotalAmount,
+		"payment_status":       order.PaymentStatus,
+		"payment_method":       order.PaymentMethod,
+		"special_instructions": order.SpecialInstructions,
+		"cancel_reason":        order.CancelReason,
+		"items":                items,
+		"created_at":           order.CreatedAt,
+	}
+	for key, value := range extra {
+		data[key] = value
+	}
+
+	s.publish(order.RestaurantID, event, data)
+}
+
+// publish queues a delivery of the event for each of the restaurant's active subscriptions
+// to it. Failures are logged rather than returned so they never fail the order itself.
+func (s *WebhookService) publish(restaurantID uint, event models.WebhookEvent, data map[string]interface{}) {
+	subscriptions, err := s.GetSubscriptions(restaurantID)
+	if err != nil {
+		log.Printf("Failed to load webhooks for restaurant %d: %v", restaurantID, err)
+		return
+	}
+
+	var deliveries []models.WebhookDelivery
+	for _, subscription := range subscriptions {
+		if subscription.IsActive && subscription.Subscribes(event) {
+			deliveries = append(deliveries, models.WebhookDelivery{SubscriptionID: subscription.ID})
+		}
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	token, err := auth.GenerateRandomToken(12)
+	if err != nil {
+		log.Printf("Failed to create %s webhook event: %v", event, err)
+		return
+	}
+	eventID := "evt_" + token
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":            eventID,
+		"event":         event,
+		"restaurant_id": restaurantID,
+		"created_at":    time.Now(),
+		"data":          data,
+	})
+	if err != nil {
+		log.Printf("Failed to encode %s webhook: %v", event, err)
+		return
+	}
+
+	now := time.Now()
+	for i := range deliveries {
+		deliveries[i].RestaurantID = restaurantID
+		deliveries[i].EventID = eventID
+		deliveries[i].Event = event
+		deliveries[i].Payload = string(payload)
+		deliveries[i].Status = models.WebhookDeliveryPending
+		deliveries[i].NextAttemptAt = now
+	}
+	if err := s.db.Create(&deliveries).Error; err != nil {
+		log.Printf("Failed to queue %s webhooks for restaurant %d: %v", event, restaurantID, err)
+		return
+	}
+
+	wakeWebhooks()
+}
+
+// wakeWebhooks tells the worker there is a delivery to send
+func wakeWebhooks() {
+	select {
+	case webhookWake <- struct{}{}:
+	default: // The worker is already due to run
+	}
+}
+
+// RunWorker sends due deliveries whenever one is queued, and every interval for retries; it never returns
+func (s *WebhookService) RunWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := s.ProcessDue()
+			if err != nil {
+				log.Printf("Failed to process webhooks: %v", err)
+			}
+			// A full batch means more deliveries may be waiting
+			if err != nil || processed < webhookBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-webhookWake:
+		}
+	}
+}
+
+// ProcessDue sends one batch of due deliveries, returning how many it attempted
+func (s *WebhookService) ProcessDue() (int, error) {
+	deliveries, err := s.claim()
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range deliveries {
+		s.attempt(&deliveries[i])
+	}
+	return len(deliveries), nil
+}
+
+// claim marks a batch of due deliveries as delivering. Rows locked by another worker are
+// skipped, so several instances can share the queue.
+func (s *WebhookService) claim() ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND updated_at < ?)",
+				models.WebhookDeliveryPending, now, models.WebhookDeliveryDelivering, now.Add(-webhookClaimTimeout)).
+			Order("next_attempt_at").
+			Limit(webhookBatchSize).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("status", models.WebhookDeliveryDelivering).Error
+	})
+	return deliveries, err
+}
+
+// attempt posts a delivery to its subscription and records the outcome
+func (s *WebhookService) attempt(delivery *models.WebhookDelivery) {
+	var subscription models.WebhookSubscription
+	err := s.db.First(&subscription, delivery.SubscriptionID).Error
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		s.recordAttempt(delivery, 0, "", 0, errors.New("webhook was deleted"), true)
+		return
+	case err != nil:
+		s.recordAttempt(delivery, 0, "", 0, err, false)
+		return
+	case !subscription.IsActive:
+		s.recordAttempt(delivery, 0, "", 0, errors.New("webhook is disabled"), true)
+		return
+	}
+
+	started := time.Now()
+	status, body, sendErr := s.send(&subscription, delivery)
+	s.recordAttempt(delivery, status, body, time.Since(started), sendErr, false)
+}
+
+// send signs and posts the payload, returning the response status and the start of its body
+func (s *WebhookService) send(subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "KenyanFoodDelivery-Webhooks/1.0")
+	req.Header.Set(WebhookHeaderEvent, string(delivery.Event))
+	req.Header.Set(WebhookHeaderEventID, delivery.EventID)
+	req.Header.Set(WebhookHeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, "sha256="+SignWebhook(subscription.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(body), fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// SignWebhook returns the hex HMAC-SHA256 signature of a payload sent at the given Unix timestamp
+func SignWebhook(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// recordAttempt marks a delivery succeeded, schedules a retry with exponential backoff, or
+// gives up once the maximum attempts are used or retrying cannot help
+func (s *WebhookService) recordAttempt(delivery *models.WebhookDelivery, responseStatus int, responseBody string, duration time.Duration, sendErr error, permanent bool) {
+	now := time.Now()
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":        attempts,
+		"response_status": responseStatus,
+		"response_body":   responseBody,
+		"duration_ms":     duration.Milliseconds(),
+	}
+
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.WebhookDeliverySucceeded
+		updates["delivered_at"] = now
+		updates["last_error"] = ""
+	case permanent || attempts >= s.config.WebhookMaxAttempts:
+		updates["status"] = models.WebhookDeliveryFailed
+		updates["last_error"] = sendErr.Error()
+		log.Printf("Giving up on webhook delivery %d (%s) after %d attempts: %v", delivery.ID, delivery.Event, attempts, sendErr)
+	default:
+		updates["status"] = models.WebhookDeliveryPending
+		updates["next_attempt_at"] = now.Add(retryBackoff(attempts, webhookBaseBackoff, webhookMaxBackoff))
+		updates["last_error"] = sendErr.Error()
+	}
+
+	if err := s.db.Model(delivery).Updates(updates).Error; err != nil {
+		log.Printf("Failed to record webhook attempt for delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// CleanupDeliveries deletes finished deliveries older than the retention period
+func (s *WebhookService) CleanupDeliveries() (int64, error) {
+	cutoff := time.Now().Add(-webhookDeliveryRetention)
+	result := s.db.Where("status IN ? AND created_at < ?",
+		[]models.WebhookDeliveryStatus{models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed}, cutoff).
+		Delete(&models.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
+
+// RunCleanup calls CleanupDeliveries every interval; it never returns
+func (s *WebhookService) RunCleanup(interval time.Duration) {
+	runPeriodically(interval, "old webhook deliveries", s.CleanupDeliveries)
+}
+
+// getSubscription loads one of a restaurant's subscriptions
+func (s *WebhookService) getSubscription(restaurantID, subscriptionID uint) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := s.db.Where("id = ? AND restaurant_id = ?", subscriptionID, restaurantID).First(&subscription).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("webhook not found")
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// validateURL requires an absolute HTTPS URL; plain HTTP is allowed outside production for local testing
+func (s *WebhookService) validateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return errors.New("webhook URL is invalid")
+	}
+	if parsed.Scheme == "https" || (parsed.Scheme == "http" && s.config.Environment != "production") {
+		return nil
+	}
+	return errors.New("webhook URL must use https")
+}
+
+// uniqueWebhookEvents drops repeated events, keeping the order given
+func uniqueWebhookEvents(events []models.WebhookEvent) []models.WebhookEvent {
+	seen := make(map[models.WebhookEvent]bool, len(events))
+	unique := make([]models.WebhookEvent, 0, len(events))
+	for _, event := range events {
+		if !seen[event] {
+			seen[event] = true
+			unique = append(unique, event)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,215 @@
+// Package templates renders the localised emails, text messages and notifications sent to users.
+// Templates are embedded in the binary: email/<locale>/<name>.html and .txt are wrapped in
+// the shared email/layout.html and layout.txt, sms/<locale>/<name>.txt are plain text, and
+// notification/<locale>/<name>.txt define a "title" above the message.
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Supported locales; DefaultLocale is used for anything else
+const (
+	LocaleEnglish = "en"
+	LocaleSwahili = "sw"
+	DefaultLocale = LocaleEnglish
+)
+
+// Locales lists the supported locales
+var Locales = []string{LocaleEnglish, LocaleSwahili}
+
+//go:embed email sms notification
+var files embed.FS
+
+// eastAfricaTime is the zone times are shown in
+var eastAfricaTime = time.FixedZone("EAT", 3*60*60)
+
+// dateTimeLayouts formats times for each locale
+var dateTimeLayouts = map[string]string{
+	LocaleEnglish: "Mon 2 Jan 2006, 15:04",
+	LocaleSwahili: "02/01/2006 saa 15:04",
+}
+
+// Email is a rendered email with HTML and plain-text bodies
+type Email struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Notification is a rendered in-app, push and SMS notification
+type Notification struct {
+	Title   string
+	Message string
+}
+
+type emailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+var (
+	emails        = make(map[string]map[string]*emailTemplate)         // locale -> name -> template
+	sms           = make(map[string]map[string]*texttemplate.Template) // locale -> name -> template
+	notifications = make(map[string]map[string]*texttemplate.Template) // locale -> name -> template
+)
+
+func init() {
+	for _, locale := range Locales {
+		emails[locale] = make(map[string]*emailTemplate)
+		sms[locale] = make(map[string]*texttemplate.Template)
+		notifications[locale] = make(map[string]*texttemplate.Template)
+		funcs := map[string]interface{}{"datetime": dateTimeFormatter(locale)}
+
+		names, err := fs.Glob(files, "email/"+locale+"/*.html")
+		if err != nil {
+			panic(err)
+		}
+		for _, file := range names {
+			name := strings.TrimSuffix(path.Base(file), ".html")
+			if name == "footer" {
+				continue
+			}
+			emails[locale][name] = &emailTemplate{
+				html: htmltemplate.Must(htmltemplate.New("layout.html").Funcs(funcs).ParseFS(files,
+					"email/layout.html", "email/"+locale+"/footer.html", file)),
+				text: texttemplate.Must(texttemplate.New("layout.txt").Funcs(funcs).ParseFS(files,
+					"email/layout.txt", "email/"+locale+"/footer.txt", "email/"+locale+"/"+name+".txt")),
+			}
+		}
+
+		names, err = fs.Glob(files, "sms/"+locale+"/*.txt")
+		if err != nil {
+			panic(err)
+		}
+		for _, file := range names {
+			name := strings.TrimSuffix(path.Base(file), ".txt")
+			sms[locale][name] = texttemplate.Must(texttemplate.New(path.Base(file)).Funcs(funcs).ParseFS(files, file))
+		}
+
+		names, err = fs.Glob(files, "notification/"+locale+"/*.txt")
+		if err != nil {
+			panic(err)
+		}
+		for _, file := range names {
+			name := strings.TrimSuffix(path.Base(file), ".txt")
+			notifications[locale][name] = texttemplate.Must(texttemplate.New(path.Base(file)).Funcs(funcs).ParseFS(files, file))
+		}
+	}
+}
+
+// NormalizeLocale maps a user's preferred language to a supported locale
+func NormalizeLocale(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if i := strings.IndexAny(language, "-_"); i >= 0 {
+		language = language[:i] // sw-KE -> sw
+	}
+	for _, locale := range Locales {
+		if language == locale {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// RenderEmail renders the named email in the recipient's language, falling back to English
+// when there is no translation. data is available to the templates alongside Locale;
+// setting UnsubscribeURL in it adds an unsubscribe link to the footer.
+func RenderEmail(language, name string, data map[string]interface{}) (*Email, error) {
+	locale := NormalizeLocale(language)
+	tmpl, ok := emails[locale][name]
+	if !ok {
+		locale = DefaultLocale
+		if tmpl, ok = emails[locale][name]; !ok {
+			return nil, fmt.Errorf("unknown email template: %s", name)
+		}
+	}
+	data = withLocale(data, locale)
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	return &Email{
+		Subject: strings.Join(strings.Fields(subject.String()), " "), // Keeps line breaks out of the header
+		HTML:    html.String(),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
+
+// RenderSMS renders the named text message in the recipient's language, falling back to English
+func RenderSMS(language, name string, data map[string]interface{}) (string, error) {
+	locale := NormalizeLocale(language)
+	tmpl, ok := sms[locale][name]
+	if !ok {
+		locale = DefaultLocale
+		if tmpl, ok = sms[locale][name]; !ok {
+			return "", fmt.Errorf("unknown SMS template: %s", name)
+		}
+	}
+
+	var message bytes.Buffer
+	if err := tmpl.Execute(&message, withLocale(data, locale)); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(message.String()), nil
+}
+
+// RenderNotification renders the named notification in the recipient's language, falling back to English
+func RenderNotification(language, name string, data map[string]interface{}) (*Notification, error) {
+	locale := NormalizeLocale(language)
+	tmpl, ok := notifications[locale][name]
+	if !ok {
+		locale = DefaultLocale
+		if tmpl, ok = notifications[locale][name]; !ok {
+			return nil, fmt.Errorf("unknown notification template: %s", name)
+		}
+	}
+	data = withLocale(data, locale)
+
+	var title, message bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&title, "title", data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(&message, data); err != nil {
+		return nil, err
+	}
+
+	return &Notification{
+		Title:   strings.TrimSpace(title.String()),
+		Message: strings.TrimSpace(message.String()),
+	}, nil
+}
+
+// withLocale copies data, adding the locale being rendered
+func withLocale(data map[string]interface{}, locale string) map[string]interface{} {
+	merged := make(map[string]interface{}, len(data)+1)
+	for key, value := range data {
+		merged[key] = value
+	}
+	merged["Locale"] = locale
+	return merged
+}
+
+// dateTimeFormatter returns the template function that shows a time in East Africa Time
+func dateTimeFormatter(locale string) func(time.Time) string {
+	layout := dateTimeLayouts[locale]
+	return func(t time.Time) string {
+		return t.In(eastAfricaTime).Format(layout)
+	}
+}
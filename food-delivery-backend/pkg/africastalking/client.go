@@ -0,0 +1,128 @@
+package africastalking
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client represents an Africa's Talking SMS API client
+type Client struct {
+	Username    string
+	APIKey      string
+	Environment string // sandbox or production
+	SenderID    string // Registered alphanumeric sender ID or short code, optional
+	BaseURL     string
+}
+
+// NewClient creates a new Africa's Talking client
+func NewClient(username, apiKey, environment, senderID string) *Client {
+	baseURL := "https://api.sandbox.africastalking.com"
+	if environment == "production" {
+		baseURL = "https://api.africastalking.com"
+	}
+
+	return &Client{
+		Username:    username,
+		APIKey:      apiKey,
+		Environment: environment,
+		SenderID:    senderID,
+		BaseURL:     baseURL,
+	}
+}
+
+// Recipient represents the outcome of a message for one phone number
+type Recipient struct {
+	StatusCode int    `json:"statusCode"`
+	Number     string `json:"number"`
+	Status     string `json:"status"`
+	Cost       string `json:"cost"` // e.g. "KES 0.8000"
+	MessageID  string `json:"messageId"`
+}
+
+// SendResponse represents the bulk SMS API response
+type SendResponse struct {
+	SMSMessageData struct {
+		Message    string      `json:"Message"`
+		Recipients []Recipient `json:"Recipients"`
+	} `json:"SMSMessageData"`
+}
+
+// DeliveryReport represents a delivery report posted to the callback URL
+type DeliveryReport struct {
+	ID            string `form:"id"`
+	Status        string `form:"status"` // Sent, Submitted, Buffered, Rejected, Success, Failed, AbsentSubscriber
+	PhoneNumber   string `form:"phoneNumber"`
+	NetworkCode   string `form:"networkCode"`
+	FailureReason string `form:"failureReason"`
+	RetryCount    int    `form:"retryCount"`
+}
+
+// Delivery report statuses that end a message's lifecycle
+const (
+	StatusSuccess  = "Success"
+	StatusFailed   = "Failed"
+	StatusRejected = "Rejected"
+)
+
+// SendSMS sends a message to one or more phone numbers in +254 format
+func (c *Client) SendSMS(to []string, message string) (*SendResponse, error) {
+	form := url.Values{}
+	form.Set("username", c.Username)
+	form.Set("to", strings.Join(to, ","))
+	form.Set("message", message)
+	form.Set("bulkSMSMode", "1")
+	if c.SenderID != "" {
+		form.Set("from", c.SenderID)
+	}
+
+	req, err := http.NewRequest("POST", c.BaseURL+"/version1/messaging", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("apiKey", c.APIKey)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to send SMS: %s", string(body))
+	}
+
+	var sendResp SendResponse
+	if err := json.Unmarshal(body, &sendResp); err != nil {
+		return nil, err
+	}
+
+	return &sendResp, nil
+}
+
+// ParseCost splits a cost string such as "KES 0.8000" into currency and amount
+func ParseCost(cost string) (string, float64) {
+	fields := strings.Fields(cost)
+	if len(fields) != 2 {
+		return "", 0
+	}
+	amount, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return fields[0], 0
+	}
+	return fields[0], amount
+}
@@ -0,0 +1,266 @@
+package fcm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// messagingScope is the OAuth scope needed to send messages
+const messagingScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// ServiceAccount holds the fields of a Google service account key file that the client uses
+type ServiceAccount struct {
+	ProjectID    string `json:"project_id"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// Client represents a Firebase Cloud Messaging HTTP v1 API client
+type Client struct {
+	ProjectID string
+	BaseURL   string
+
+	account    ServiceAccount
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	tokenExpiry time.Time
+}
+
+// NewClient creates a client from a service account key file. projectID overrides the
+// project named in the key file when set.
+func NewClient(credentialsFile, projectID string) (*Client, error) {
+	data, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FCM credentials: %w", err)
+	}
+
+	var account ServiceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("invalid FCM credentials: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, errors.New("invalid FCM credentials: client_email and private_key are required")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	if projectID == "" {
+		projectID = account.ProjectID
+	}
+	if projectID == "" {
+		return nil, errors.New("FCM project ID is not set")
+	}
+
+	return &Client{
+		ProjectID:  projectID,
+		BaseURL:    "https://fcm.googleapis.com",
+		account:    account,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Notification is the title and body shown by the device
+type Notification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+// Message represents a message to a single registration token
+type Message struct {
+	Token        string            `json:"token"`
+	Notification *Notification     `json:"notification,omitempty"`
+	Data         map[string]string `json:"data,omitempty"`
+	Android      *AndroidConfig    `json:"android,omitempty"`
+	APNS         *APNSConfig       `json:"apns,omitempty"`
+}
+
+// AndroidConfig holds Android-specific delivery options
+type AndroidConfig struct {
+	Priority string `json:"priority,omitempty"` // normal or high
+}
+
+// APNSConfig holds Apple-specific delivery options
+type APNSConfig struct {
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Error is an error response from the FCM API
+type Error struct {
+	StatusCode int
+	Status     string // Google API status, e.g. NOT_FOUND
+	ErrorCode  string // FCM error code, e.g. UNREGISTERED
+	Message    string
+}
+
+func (e *Error) Error() string {
+	code := e.ErrorCode
+	if code == "" {
+		code = e.Status
+	}
+	return fmt.Sprintf("FCM error %d %s: %s", e.StatusCode, code, e.Message)
+}
+
+// InvalidToken reports whether the error means the registration token will never work
+// again, so it should be forgotten
+func (e *Error) InvalidToken() bool {
+	switch e.ErrorCode {
+	case "UNREGISTERED", "SENDER_ID_MISMATCH":
+		return true
+	case "INVALID_ARGUMENT":
+		return strings.Contains(strings.ToLower(e.Message), "registration token")
+	}
+	return false
+}
+
+// errorResponse is the body FCM returns on failure
+type errorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			Type      string `json:"@type"`
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// Send delivers a message and returns the message name FCM assigned to it
+func (c *Client) Send(message *Message) (string, error) {
+	token, err := c.token()
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{"message": message})
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/messages:send", c.BaseURL, url.PathEscape(c.ProjectID))
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", parseError(resp.StatusCode, body)
+	}
+
+	var result struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", err
+	}
+	return result.Name, nil
+}
+
+// token returns a cached OAuth access token, exchanging a signed service account
+// assertion for a new one shortly before the old one expires
+func (c *Client) token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != "" && time.Now().Add(time.Minute).Before(c.tokenExpiry) {
+		return c.accessToken, nil
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(c.account.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("invalid FCM private key: %w", err)
+	}
+
+	now := time.Now()
+	assertion := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   c.account.ClientEmail,
+		"scope": messagingScope,
+		"aud":   c.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	assertion.Header["kid"] = c.account.PrivateKeyID
+	signed, err := assertion.SignedString(key)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", signed)
+
+	resp, err := c.httpClient.PostForm(c.account.TokenURI, form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get FCM access token: %s", string(body))
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", err
+	}
+
+	c.accessToken = result.AccessToken
+	c.tokenExpiry = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+	return c.accessToken, nil
+}
+
+// parseError turns an FCM error body into an *Error
+func parseError(statusCode int, body []byte) error {
+	var parsed errorResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return &Error{StatusCode: statusCode, Message: string(body)}
+	}
+
+	fcmErr := &Error{
+		StatusCode: statusCode,
+		Status:     parsed.Error.Status,
+		Message:    parsed.Error.Message,
+	}
+	for _, detail := range parsed.Error.Details {
+		if detail.ErrorCode != "" {
+			fcmErr.ErrorCode = detail.ErrorCode
+			break
+		}
+	}
+	return fcmErr
+}
@@ -1,7 +1,9 @@
 package location
 
 import (
+	"errors"
 	"math"
+	"strings"
 )
 
 // County represents a Kenyan county
@@ -201,6 +203,35 @@ func IsWithinDeliveryRadius(restaurantLat, restaurantLon, customerLat, customerL
 	return distance <= maxRadius
 }
 
+// NormalizePhoneNumber converts a Kenyan mobile number (07.., 01.., 254.., +254..) to +254XXXXXXXXX
+func NormalizePhoneNumber(phoneNumber string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phoneNumber)
+
+	switch {
+	case len(digits) == 12 && strings.HasPrefix(digits, "254"):
+		digits = digits[3:]
+	case len(digits) == 10 && strings.HasPrefix(digits, "0"):
+		digits = digits[1:]
+	}
+
+	if len(digits) != 9 || (digits[0] != '7' && digits[0] != '1') {
+		return "", errors.New("invalid Kenyan mobile number")
+	}
+
+	return "+254" + digits, nil
+}
+
+// PhoneNumberVariants returns the common ways a normalized number may have been stored
+func PhoneNumberVariants(normalized string) []string {
+	local := strings.TrimPrefix(normalized, "+254")
+	return []string{normalized, "254" + local, "0" + local}
+}
+
 // PopularKenyanCuisines contains popular Kenyan cuisine types
 var PopularKenyanCuisines = []string{
 	"Kenyan Traditional",
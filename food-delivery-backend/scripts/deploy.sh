@@ -117,6 +117,15 @@ gcloud run deploy ${SERVICE_NAME} \
   --set-env-vars "MPESA_PASSKEY=${MPESA_PASSKEY}" \
   --set-env-vars "MPESA_SHORTCODE=${MPESA_SHORTCODE}" \
   --set-env-vars "MPESA_ENVIRONMENT=${MPESA_ENVIRONMENT}" \
+  --set-env-vars "SMS_PROVIDER=${SMS_PROVIDER}" \
+  --set-env-vars "SMS_SENDER_ID=${SMS_SENDER_ID}" \
+  --set-env-vars "SMS_CALLBACK_TOKEN=${SMS_CALLBACK_TOKEN}" \
+  --set-env-vars "AFRICASTALKING_USERNAME=${AFRICASTALKING_USERNAME}" \
+  --set-env-vars "AFRICASTALKING_API_KEY=${AFRICASTALKING_API_KEY}" \
+  --set-env-vars "AFRICASTALKING_ENVIRONMENT=${AFRICASTALKING_ENVIRONMENT}" \
+  --set-env-vars "SUPER_ADMIN_EMAILS=${SUPER_ADMIN_EMAILS}" \
+  --set-env-vars "^;^TRUSTED_PROXIES=${TRUSTED_PROXIES}" \
+  --set-env-vars "TRUSTED_PLATFORM=${TRUSTED_PLATFORM}" \
   --set-env-vars "CLOUDINARY_CLOUD_NAME=${CLOUDINARY_CLOUD_NAME}" \
   --set-env-vars "CLOUDINARY_API_KEY=${CLOUDINARY_API_KEY}" \
   --set-env-vars "CLOUDINARY_API_SECRET=${CLOUDINARY_API_SECRET}" \